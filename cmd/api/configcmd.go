@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/developwithayush/go-todo-app/internal/config"
+)
+
+const configUsage = `usage: api [flags] config print [-format yaml|env]
+
+print  show the effective configuration after defaults, the config file,
+       env variables and flags are applied, with secrets redacted`
+
+// runConfig implements the config subcommand and returns the exit code.
+// The configuration is printed even when it is invalid, followed by the
+// problems found in it.
+func runConfig(cfg *config.Config, loadErr error, args []string) int {
+	if errors.Is(loadErr, flag.ErrHelp) {
+		return 0
+	}
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config print", flag.ContinueOnError)
+	format := fs.String("format", "yaml", "output format: yaml or env")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if err := cfg.Print(os.Stdout, *format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if loadErr != nil {
+		fmt.Fprintln(os.Stderr, "invalid configuration:\n"+loadErr.Error())
+		return 1
+	}
+	return 0
+}
@@ -1,13 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/developwithayush/go-todo-app/internal/cache"
 	"github.com/developwithayush/go-todo-app/internal/config"
 	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/domain/todo"
+	"github.com/developwithayush/go-todo-app/internal/domain/user"
+	"github.com/developwithayush/go-todo-app/internal/health"
 	"github.com/developwithayush/go-todo-app/internal/http"
+	"github.com/developwithayush/go-todo-app/internal/jobs"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
+	"github.com/developwithayush/go-todo-app/internal/realtime"
+	"github.com/developwithayush/go-todo-app/internal/telemetry"
+	"github.com/developwithayush/go-todo-app/internal/validate"
 	"github.com/gofiber/fiber/v3"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 
 	_ "github.com/developwithayush/go-todo-app/docs" // Swagger docs
 )
@@ -28,40 +49,206 @@ import (
 // @securityDefinitions.apikey CookieAuth
 // @in cookie
 // @name todo_app
-// @description JWT token stored in HTTP-only cookie. Obtain token by verifying OTP at /auth/verify-otp endpoint.
+// @description JWT token stored in HTTP-only cookie. Obtain token by verifying OTP at /auth/verify-otp endpoint. POST, PUT, PATCH and DELETE requests must also send a token from /auth/csrf in the X-CSRF-Token header.
 
 func main() {
 	_ = godotenv.Load()
 
-	cfg := config.Load()
+	cfg, args, err := config.Load(os.Args[1:])
+	if len(args) > 0 && args[0] == "config" {
+		os.Exit(runConfig(cfg, err, args[1:]))
+	}
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, "invalid configuration:\n"+err.Error())
+		os.Exit(2)
+	}
 	logr := logger.NewLogger(cfg)
 
 	defer logr.Sync()
 
-	if err := db.InitMongo(cfg, logr); err != nil {
-		logr.Fatal("Failed to initialize MongoDB", logger.Field("error", err))
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(cfg, logr, args[1:]); err != nil {
+			logr.Fatal("Migration failed", logger.Field("error", err))
+		}
+		return
 	}
 
-	if err := cache.InitRedis(cfg, logr); err != nil {
-		logr.Error("failed to connect redis", logger.Field(
-			"error", err))
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	migrateCtx, cancelMigrate := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancelMigrate()
+
+	// tracing goes first so the clients created below are instrumented
+	closeTracing, err := telemetry.Setup(ctx, cfg)
+	if err != nil {
+		logr.Fatal("Failed to set up tracing", logger.Field("error", err))
+	}
+
+	// closed last, after the HTTP server and the hub registered below
+	var closeStorage, closeCache func(ctx context.Context) error
+	checks := health.NewChecker(logr)
+
+	var userRepo user.Repository
+	var todoRepo todo.Repository
+	switch cfg.StorageDriver {
+	case db.DriverMongo:
+		if err := db.InitMongo(cfg, logr); err != nil {
+			logr.Fatal("Failed to initialize MongoDB", logger.Field("error", err))
+		}
+		closeStorage = db.Client.Disconnect
+		checks.Add(health.Check{Name: "mongo", Critical: true, Run: func(ctx context.Context) error {
+			return db.Client.Ping(ctx, readpref.Primary())
+		}})
+		checks.Add(health.Check{Name: "migrations", Critical: true, TTL: time.Minute, Run: func(ctx context.Context) error {
+			statuses, err := db.MongoMigrationStatus(ctx, db.DB)
+			if err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				if s.AppliedAt == nil {
+					return fmt.Errorf("migration %d (%s) is pending", s.Version, s.Description)
+				}
+			}
+			return nil
+		}})
+		if cfg.MigrateOnStart {
+			if err := db.MigrateMongoUp(migrateCtx, db.DB, logr); err != nil {
+				logr.Fatal("Failed to migrate MongoDB", logger.Field("error", err))
+			}
+		}
+		userRepo = user.NewRepository(db.DB)
+		todoRepo = todo.NewRepository(db.DB)
+	case db.DriverPostgres, db.DriverSQLite:
+		sqlDB, err := db.OpenSQL(cfg, logr)
+		if err != nil {
+			logr.Fatal("Failed to initialize SQL database", logger.Field("error", err))
+		}
+		closeStorage = func(context.Context) error { return sqlDB.Close() }
+		metrics.RegisterDB(sqlDB.DB, cfg.StorageDriver)
+		checks.Add(health.Check{Name: cfg.StorageDriver, Critical: true, Run: sqlDB.PingContext})
+		checks.Add(health.Check{Name: "migrations", Critical: true, TTL: time.Minute, Run: func(ctx context.Context) error {
+			pending, err := sqlDB.PendingMigrations(ctx)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("migrations pending: %s", strings.Join(pending, ", "))
+			}
+			return nil
+		}})
+		if cfg.MigrateOnStart {
+			if err := sqlDB.Migrate(migrateCtx); err != nil {
+				logr.Fatal("Failed to migrate SQL database", logger.Field("error", err))
+			}
+		}
+		userRepo = user.NewSQLRepository(sqlDB)
+		todoRepo = todo.NewSQLRepository(sqlDB)
+	case db.DriverMemory:
+		logr.Warn("Using in-memory storage; data is lost on restart")
+		userRepo = user.NewMemoryRepository()
+		todoRepo = todo.NewMemoryRepository()
+	default:
+		logr.Fatal("Unknown storage driver", logger.Field("driver", cfg.StorageDriver))
+	}
+
+	var store cache.Store
+	var redisClient *redis.Client
+	switch cfg.CacheDriver {
+	case "redis":
+		client, err := cache.NewRedisClient(cfg)
+		if err != nil {
+			logr.Fatal("Failed to create Redis client", logger.Field("error", err))
+		}
+		redisStore := cache.NewRedisStore(client, logr)
+		store, redisClient = redisStore, redisStore.Client()
+		closeCache = func(context.Context) error { return redisStore.Close() }
+		// caching, rate limits and idempotency fail open without Redis
+		checks.Add(health.Check{Name: "redis", Run: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}})
+	case "memory":
+		store = cache.NewMemoryStore(cfg.CacheSize)
+	default:
+		logr.Fatal("Unknown cache driver", logger.Field("driver", cfg.CacheDriver))
+	}
+	if cfg.ListCacheTTL > 0 {
+		todoRepo = todo.NewCachedRepository(todoRepo, store, cfg.ListCacheTTL, logr)
 	}
 
+	hub := realtime.NewHub(redisClient, logr)
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	hubDone := make(chan struct{})
+	go func() {
+		defer close(hubDone)
+		hub.Run(hubCtx)
+	}()
+
 	app := fiber.New(fiber.Config{
-		AppName:   "Go Todo App",
-		BodyLimit: 1024 * 1024 * 10, // 10MB
-		ErrorHandler: func(c fiber.Ctx, err error) error {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"message": "Internal Server Error",
-			})
-		},
+		AppName:      "Go Todo App",
+		BodyLimit:    1024 * 1024 * 10, // 10MB
+		ErrorHandler: http.ErrorHandler,
+		// reject unknown fields and validate every bound DTO
+		JSONDecoder:     validate.DecodeJSON,
+		StructValidator: validate.Validator{},
 	})
-	http.RegisterRoutes(app, cfg, logr)
+	// background jobs such as large imports
+	runner := jobs.NewRunner(store, logr)
+	http.RegisterRoutes(app, cfg, logr, checks, hub, runner, store, userRepo, todoRepo)
+
+	port := strconv.Itoa(cfg.Port)
+	logr.Info("Server is running on port " + port)
 
-	logr.Info("Server is running on port " + cfg.Port)
+	listenErr := make(chan error, 2)
+	go func() { listenErr <- app.Listen(":" + port) }()
+
+	var admin *fiber.App
+	if cfg.AdminPort != 0 {
+		admin = http.NewAdminApp(checks)
+		adminPort := strconv.Itoa(cfg.AdminPort)
+		logr.Info("Admin server is running on port " + adminPort)
+		go func() {
+			listenErr <- admin.Listen(":"+adminPort, fiber.ListenConfig{DisableStartupMessage: true})
+		}()
+	}
 
-	if err := app.Listen(":" + cfg.Port); err != nil {
+	select {
+	case err := <-listenErr:
 		logr.Fatal("Failed to start server", logger.Field("error", err))
+	case <-ctx.Done():
 	}
+	// a second signal kills the process without waiting for the drain
+	stop()
+	logr.Info("Shutting down", logger.Field("timeout", cfg.ShutdownTimeout))
+
+	deps := shutdownDeps{
+		drainDelay: cfg.ShutdownDelay,
+		notReady:   checks.SetShuttingDown,
+		hub: func(ctx context.Context) error {
+			stopHub()
+			select {
+			case <-hubDone:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+		server:  app.ShutdownWithContext,
+		jobs:    runner.Shutdown,
+		storage: closeStorage,
+		cache:   closeCache,
+		tracing: closeTracing,
+	}
+	if admin != nil {
+		deps.admin = admin.ShutdownWithContext
+	}
+	steps := newShutdown(logr, deps)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	steps.run(shutdownCtx)
+	logr.Info("Server stopped")
 }
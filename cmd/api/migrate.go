@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+)
+
+const migrateUsage = `usage: api migrate [up | down [steps] | status]
+
+up      apply all pending migrations (default)
+down    revert the last steps migrations, 1 by default (mongo only)
+status  list migrations and when they were applied (mongo only)`
+
+// runMigrate implements the migrate subcommand for the configured storage
+// driver.
+func runMigrate(cfg *config.Config, logr logger.Logger, args []string) error {
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if cfg.StorageDriver == db.DriverMemory {
+		return fmt.Errorf("the memory driver has no schema to migrate")
+	}
+	if cfg.StorageDriver != db.DriverMongo {
+		if cmd != "up" {
+			return fmt.Errorf("%s is only supported with the mongo driver\n%s", cmd, migrateUsage)
+		}
+		sqlDB, err := db.OpenSQL(cfg, logr)
+		if err != nil {
+			return err
+		}
+		defer sqlDB.Close()
+		return sqlDB.Migrate(ctx)
+	}
+
+	if err := db.InitMongo(cfg, logr); err != nil {
+		return err
+	}
+	defer db.Client.Disconnect(context.Background())
+
+	switch cmd {
+	case "up":
+		return db.MigrateMongoUp(ctx, db.DB, logr)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid steps %q\n%s", args[1], migrateUsage)
+			}
+			steps = n
+		}
+		return db.MigrateMongoDown(ctx, db.DB, logr, steps)
+	case "status":
+		statuses, err := db.MongoMigrationStatus(ctx, db.DB)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, applied, s.Description)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", cmd, migrateUsage)
+	}
+}
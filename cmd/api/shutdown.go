@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/logger"
+)
+
+// shutdownStep releases one resource. Steps run in the order they were
+// added, so register consumers before the things they depend on.
+type shutdownStep struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+type shutdown struct {
+	logr  logger.Logger
+	steps []shutdownStep
+}
+
+// shutdownDeps are the resources the server releases on shutdown. Nil
+// funcs are skipped, so optional parts such as the admin server or a cache
+// without a connection can be left out.
+type shutdownDeps struct {
+	// drainDelay is how long the server keeps answering after readiness
+	// fails, for load balancers to notice
+	drainDelay time.Duration
+	// notReady makes readiness probes fail
+	notReady func()
+	// hub ends realtime streams; they only end when the hub closes their
+	// channels, so it stops before the server drains or they would hold
+	// it until the deadline
+	hub     func(ctx context.Context) error
+	server  func(ctx context.Context) error
+	jobs    func(ctx context.Context) error
+	admin   func(ctx context.Context) error
+	storage func(ctx context.Context) error
+	cache   func(ctx context.Context) error
+	tracing func(ctx context.Context) error
+}
+
+// newShutdown orders the steps of d: stop accepting traffic, drain
+// in-flight requests, stop background jobs, then close the stores they
+// write to.
+func newShutdown(logr logger.Logger, d shutdownDeps) *shutdown {
+	s := &shutdown{logr: logr}
+	if d.notReady != nil {
+		s.add("readiness", func(ctx context.Context) error {
+			d.notReady()
+			select {
+			case <-time.After(d.drainDelay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}
+	s.add("realtime hub", d.hub)
+	s.add("http server", d.server)
+	// after the server so no new job starts, before the storage they write to
+	s.add("background jobs", d.jobs)
+	// kept until the main server drained so probes and scrapes still work
+	s.add("admin server", d.admin)
+	s.add("storage", d.storage)
+	s.add("cache", d.cache)
+	s.add("tracing", d.tracing)
+	return s
+}
+
+func (s *shutdown) add(name string, fn func(ctx context.Context) error) {
+	if fn == nil {
+		return
+	}
+	s.steps = append(s.steps, shutdownStep{name: name, fn: fn})
+}
+
+// run executes every step within ctx's deadline. A failing step is logged
+// and the remaining ones still run, so one stuck resource does not keep
+// the others open: once the deadline passed, steps get a done ctx and
+// should release what they can without waiting.
+func (s *shutdown) run(ctx context.Context) {
+	for _, step := range s.steps {
+		start := time.Now()
+		if err := step.fn(ctx); err != nil {
+			s.logr.Error("shutdown step failed", logger.Field("step", step.name), logger.Field("error", err))
+			continue
+		}
+		s.logr.Info("shutdown step done", logger.Field("step", step.name),
+			logger.Field("duration", time.Since(start)))
+	}
+}
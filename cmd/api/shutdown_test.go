@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recorder fakes the resources of shutdownDeps and records the order they
+// are released in.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recorder) record(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, name)
+}
+
+func (r *recorder) step(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		r.record(name)
+		return nil
+	}
+}
+
+func (r *recorder) deps() shutdownDeps {
+	return shutdownDeps{
+		notReady: func() { r.record("not ready") },
+		hub:      r.step("hub"),
+		server:   r.step("server"),
+		jobs:     r.step("jobs"),
+		admin:    r.step("admin"),
+		storage:  r.step("storage"),
+		cache:    r.step("cache"),
+		tracing:  r.step("tracing"),
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	rec := &recorder{}
+	deps := rec.deps()
+
+	// a request in flight when the server starts draining; jobs may only
+	// stop once it has finished
+	inFlight := make(chan struct{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rec.record("request finished")
+		close(inFlight)
+	}()
+	deps.server = func(ctx context.Context) error {
+		rec.record("server")
+		select {
+		case <-inFlight:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	newShutdown(zap.NewNop(), deps).run(ctx)
+
+	want := []string{"not ready", "hub", "server", "request finished", "jobs", "admin", "storage", "cache", "tracing"}
+	if !slices.Equal(rec.calls, want) {
+		t.Fatalf("shutdown ran\n  %v\nwant\n  %v", rec.calls, want)
+	}
+}
+
+func TestShutdownSkipsMissingSteps(t *testing.T) {
+	rec := &recorder{}
+	deps := rec.deps()
+	deps.notReady, deps.admin, deps.cache = nil, nil, nil
+
+	newShutdown(zap.NewNop(), deps).run(context.Background())
+
+	want := []string{"hub", "server", "jobs", "storage", "tracing"}
+	if !slices.Equal(rec.calls, want) {
+		t.Fatalf("shutdown ran %v, want %v", rec.calls, want)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	rec := &recorder{}
+	deps := rec.deps()
+	// a server whose connections never drain
+	deps.server = func(ctx context.Context) error {
+		rec.record("server")
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	deps.jobs = func(ctx context.Context) error {
+		rec.record("jobs")
+		if ctx.Err() == nil {
+			t.Error("jobs got a live ctx after the deadline")
+		}
+		return ctx.Err()
+	}
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	newShutdown(zap.New(core), deps).run(ctx)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown took %v with a 50ms timeout", elapsed)
+	}
+	// the stuck server does not keep the stores open
+	want := []string{"not ready", "hub", "server", "jobs", "admin", "storage", "cache", "tracing"}
+	if !slices.Equal(rec.calls, want) {
+		t.Fatalf("shutdown ran %v, want %v", rec.calls, want)
+	}
+
+	var failed []string
+	for _, entry := range logs.FilterMessage("shutdown step failed").All() {
+		step := entry.ContextMap()["step"].(string)
+		if err, _ := entry.ContextMap()["error"].(string); err != context.DeadlineExceeded.Error() {
+			t.Errorf("step %s failed with %q", step, err)
+		}
+		failed = append(failed, step)
+	}
+	if !slices.Equal(failed, []string{"http server", "background jobs"}) {
+		t.Fatalf("failed steps %v, want the http server and background jobs", failed)
+	}
+}
+
+func TestShutdownDrainDelay(t *testing.T) {
+	deps := (&recorder{}).deps()
+	deps.drainDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	core, logs := observer.New(zapcore.InfoLevel)
+	newShutdown(zap.New(core), deps).run(ctx)
+
+	// the delay ends with the deadline rather than outlasting it
+	entries := logs.FilterMessage("shutdown step failed").FilterField(zap.Any("step", "readiness")).All()
+	if len(entries) != 1 {
+		t.Fatalf("readiness step did not fail at the deadline: %v", logs.All())
+	}
+}
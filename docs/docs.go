@@ -22,6 +22,37 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/auth/csrf": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Returns a CSRF token for the current session and also sets it in a readable cookie. Authenticated POST, PUT, PATCH and DELETE requests must send it in the X-CSRF-Token header. It stops working when the session changes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Get a CSRF token",
+                "responses": {
+                    "200": {
+                        "description": "CSRF token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.CSRFTokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/auth/send-otp": {
             "post": {
                 "description": "Sends a one-time password (OTP) to the provided email address for authentication. The OTP is valid for 10 minutes.",
@@ -114,6 +145,168 @@ const docTemplate = `{
                 }
             }
         },
+        "/stream": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Opens a Server-Sent Events stream of todo.created, todo.updated, todo.deleted and todo.reordered events for the authenticated user. Reconnecting clients send Last-Event-ID to receive the events they missed.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Stream todo changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "1700000000000-0",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Last-Event-ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/sync": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Returns todos created or updated and tombstones for todos deleted after the given change token, ordered by change sequence. Omit since for a full download. When hasMore is true, call again with the returned token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sync"
+                ],
+                "summary": "Get changes since a sync token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "42",
+                        "description": "Change token from a previous sync",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changes since the token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.SyncPullResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sync token",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to load changes",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Applies a batch of client mutations in order. An update or delete whose baseSeq is older than the server copy is reported as a conflict together with the server todo; other mutations are applied independently. Creates may carry a client-generated ID so retries are idempotent.\nThe returned token is since, the token of the client's last pull, so the next pull also brings the changes other devices made in between. Without since it is the sequence from before the push.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sync"
+                ],
+                "summary": "Apply offline changes",
+                "parameters": [
+                    {
+                        "description": "Client mutations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.SyncPushRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per-mutation results",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.SyncPushResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to apply changes",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/todos": {
             "get": {
                 "security": [
@@ -132,13 +325,30 @@ const docTemplate = `{
                     "Todos"
                 ],
                 "summary": "List all todos for authenticated user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "List ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "List of todos",
                         "schema": {
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoListResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the user's todo list"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "List unchanged since the given ETag"
+                    },
                     "401": {
                         "description": "Unauthorized - Invalid or missing authentication",
                         "schema": {
@@ -159,7 +369,7 @@ const docTemplate = `{
                         "CookieAuth": []
                     }
                 ],
-                "description": "Creates a new todo item for the authenticated user",
+                "description": "Creates a new todo item for the authenticated user. Status defaults to the workflow's initial status.",
                 "consumes": [
                     "application/json"
                 ],
@@ -186,6 +396,12 @@ const docTemplate = `{
                         "description": "Created todo",
                         "schema": {
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoCreateResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the created todo"
+                            }
                         }
                     },
                     "400": {
@@ -200,6 +416,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Failed to create todo",
                         "schema": {
@@ -209,14 +431,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/todos/{id}": {
-            "put": {
+        "/todos/batch": {
+            "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Updates an existing todo item for the authenticated user",
+                "description": "Applies create, update, delete, move and complete operations in order and returns a result for each one. By default operations succeed or fail independently. With atomic=true the batch runs in a transaction: the first failure rolls everything back and the response is a 422 problem whose results member lists each operation. Atomic batches need a database with transactions; a standalone MongoDB answers them with 501.",
                 "consumes": [
                     "application/json"
                 ],
@@ -226,35 +448,33 @@ const docTemplate = `{
                 "tags": [
                     "Todos"
                 ],
-                "summary": "Update a todo",
+                "summary": "Run a batch of todo operations",
                 "parameters": [
                     {
-                        "type": "string",
-                        "example": "507f1f77bcf86cd799439011",
-                        "description": "Todo ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "boolean",
+                        "description": "Apply all operations or none",
+                        "name": "atomic",
+                        "in": "query"
                     },
                     {
-                        "description": "Updated todo details",
+                        "description": "Operations",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.UpdateTodoRequest"
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BatchRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Todo updated successfully",
+                        "description": "Per-operation results",
                         "schema": {
-                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.MessageResponse"
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BatchResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or todo ID",
+                        "description": "Invalid request body or atomic flag",
                         "schema": {
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
                         }
@@ -265,21 +485,41 @@ const docTemplate = `{
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Atomic batch rolled back",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BatchProblemResponse"
+                        }
+                    },
                     "500": {
-                        "description": "Failed to update todo",
+                        "description": "Failed to run batch",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Atomic batches need a database with transactions",
                         "schema": {
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/todos/board": {
+            "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Deletes an existing todo item for the authenticated user",
+                "description": "Retrieves the authenticated user's todos grouped into one column per workflow status, in workflow order. Todos within a column are sorted by position. The workflow is configured for the whole deployment; per-project workflows are not supported.",
                 "consumes": [
                     "application/json"
                 ],
@@ -289,57 +529,1099 @@ const docTemplate = `{
                 "tags": [
                     "Todos"
                 ],
-                "summary": "Delete a todo",
-                "parameters": [
+                "summary": "Get todos as a board",
+                "responses": {
+                    "200": {
+                        "description": "Board columns",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BoardResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to load board",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/complete-all": {
+            "post": {
+                "security": [
                     {
-                        "type": "string",
-                        "example": "507f1f77bcf86cd799439011",
-                        "description": "Todo ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "CookieAuth": []
                     }
                 ],
+                "description": "Moves every todo of the authenticated user that is not in a done status to the done status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Complete all open todos",
                 "responses": {
                     "200": {
-                        "description": "Todo deleted successfully",
+                        "description": "Number of todos completed",
                         "schema": {
-                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.MessageResponse"
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.CountResponse"
                         }
                     },
-                    "400": {
-                        "description": "Invalid todo ID",
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
                         "schema": {
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized - Invalid or missing authentication",
+                    "403": {
+                        "description": "CSRF check failed",
                         "schema": {
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Failed to delete todo",
+                        "description": "Failed to complete todos",
                         "schema": {
                             "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "github_com_developwithayush_go-todo-app_internal_dto.CreateTodoRequest": {
-            "description": "Request body for creating a new todo item",
-            "type": "object",
-            "required": [
-                "title"
-            ],
+        },
+        "/todos/completed": {
+            "delete": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Deletes every todo of the authenticated user that is in a done status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Delete all completed todos",
+                "responses": {
+                    "200": {
+                        "description": "Number of todos deleted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.CountResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete todos",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/export": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Downloads all todos of the authenticated user as JSON (an array of records), CSV (with a header row), todo.txt or a Markdown checklist grouped by status. todo.txt keeps the status in a status: tag and leaves out descriptions.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/plain",
+                    "text/markdown"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Export todos",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "todotxt",
+                            "markdown"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "File format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Todos in the requested format",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoRecord"
+                            }
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=todos.json"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown format",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to export todos",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/import": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Creates todos from a file in the request body: JSON (an array of records as exported), CSV with a header row (title is required, id, description, status, completed, completed_at and created_at are optional), todo.txt or a Markdown checklist. The format comes from the format parameter or else from Content-Type.\nRows are imported on their own: a row that fails or duplicates an existing todo (by title by default) is reported and the rest are still created. With dryRun=true nothing is written and the report shows what would happen.\nFiles of more than 500 rows, or any file with async=true, are imported by a background job: the response is 202 with the job, and its Location is polled until the job has finished. Files may have at most 10000 rows.",
+                "consumes": [
+                    "application/json",
+                    "text/csv",
+                    "text/plain",
+                    "text/markdown"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Import todos",
+                "parameters": [
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "todotxt",
+                            "markdown"
+                        ],
+                        "type": "string",
+                        "description": "File format, instead of Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Report what would be imported without writing",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "title",
+                            "id",
+                            "none"
+                        ],
+                        "type": "string",
+                        "default": "title",
+                        "description": "Skip rows matching an existing todo or an earlier row",
+                        "name": "dedupe",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Run as a background job regardless of size",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Import file",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import report",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ImportResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Import job started",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.JobResponse"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the import job"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid file or options",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unknown format",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to import todos",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/import/jobs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Returns the progress of a background import, and its report once it has succeeded. Jobs are kept for 24 hours.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Get an import job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "65a1b2c3d4e5f6a7b8c9d0e1",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Import job",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.JobResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Job not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to load import job",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/todos/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Retrieves a single todo item belonging to the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Get a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "507f1f77bcf86cd799439011",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The todo",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoCreateResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version of the todo"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Todo unchanged since the given ETag"
+                    },
+                    "400": {
+                        "description": "Invalid todo ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Todo not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to get todo",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Updates an existing todo item for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Update a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "507f1f77bcf86cd799439011",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update if the todo still has this ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Updated todo details",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.UpdateTodoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Todo updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.MessageResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the todo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or todo ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Todo not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Todo was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update todo",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Deletes an existing todo item for the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Delete a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "507f1f77bcf86cd799439011",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only delete if the todo still has this ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Todo deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid todo ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Todo not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Todo was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to delete todo",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Applies a JSON Merge Patch (RFC 7396, application/merge-patch+json or application/json) or a JSON Patch (RFC 6902, application/json-patch+json) to the todo's title, description, status, completed and position. In a merge patch, null clears a field. Setting completed moves the todo to the done status, or back to the initial status.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Todos"
+                ],
+                "summary": "Partially update a todo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "507f1f77bcf86cd799439011",
+                        "description": "Todo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only update if the todo still has this ETag",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Merge patch, or an array of JSON Patch operations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.PatchTodoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated todo",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoCreateResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the todo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid patch or todo ID",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing authentication",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "CSRF check failed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Todo not found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Todo was modified since the given ETag",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported patch format",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update todo",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_developwithayush_go-todo-app_internal_dto.BatchOperation": {
+            "description": "One create, update, delete, move or complete operation. Version, when set, must match the todo's current version.",
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "completed": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Milk, eggs, bread"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete",
+                        "move",
+                        "complete"
+                    ],
+                    "example": "complete"
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 2
+                },
+                "status": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "in_progress"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Buy groceries"
+                },
+                "version": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 3
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.BatchProblemResponse": {
+            "description": "Problem details with the outcome of each operation: the one that failed, rolled_back for those before it",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "todo_not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "Todo not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/todos/507f1f77bcf86cd799439011"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "3f2b8c0d9e1a4b5c6d7e8f9a0b1c2d3e"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BatchResultResponse"
+                    }
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.BatchRequest": {
+            "description": "Operations applied in order",
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BatchOperation"
+                    }
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.BatchResponse": {
+            "description": "Per-operation results in request order",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BatchResultResponse"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.BatchResultResponse": {
+            "description": "Outcome of one operation: ok, failed, or rolled_back when an atomic batch was aborted",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "todo not found"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "complete"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "todo": {
+                    "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoResponse"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.BoardColumnResponse": {
+            "description": "Todos in one status, ordered by position",
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "in_progress"
+                },
+                "todos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoResponse"
+                    }
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.BoardResponse": {
+            "description": "Response containing one column per workflow status",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.BoardColumnResponse"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.CSRFTokenResponse": {
+            "description": "CSRF token for cookie-authenticated requests",
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string",
+                    "example": "q3mZ0f1x2Q5vB8nT4kLw7A.Hq0mV4nS1fWk9eC2rJ6uXy3zP8tLb5aD7gNv0oQ1iE"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.CountResponse": {
+            "description": "Number of todos affected by a bulk action",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "count": {
+                            "type": "integer",
+                            "example": 12
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.CreateTodoRequest": {
+            "description": "Request body for creating a new todo item",
+            "type": "object",
+            "required": [
+                "title"
+            ],
             "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#1e90ff"
+                },
                 "description": {
                     "type": "string",
-                    "example": "Milk, eggs, bread"
+                    "maxLength": 2000,
+                    "example": "Milk, eggs, bread"
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO,WE"
+                },
+                "status": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "todo"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Buy groceries"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse": {
+            "description": "RFC 7807 problem details, sent as application/problem+json",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "todo_not_found"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "Todo not found"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/todos/507f1f77bcf86cd799439011"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "3f2b8c0d9e1a4b5c6d7e8f9a0b1c2d3e"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.FieldError": {
+            "description": "Validation failure for one field",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "required"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "title"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Title is required"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.ImportReportResponse": {
+            "description": "Row counts and per-row outcomes of an import",
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer",
+                    "example": 117
+                },
+                "dryRun": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "format": {
+                    "type": "string",
+                    "example": "csv"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ImportRowResponse"
+                    }
+                },
+                "skipped": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.ImportResponse": {
+            "description": "Import report",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ImportReportResponse"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.ImportRowResponse": {
+            "description": "Outcome of one row: created, valid (dry run), skipped as a duplicate, or failed",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "title is required"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "row": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "status": {
+                    "type": "string",
+                    "example": "failed"
                 },
                 "title": {
                     "type": "string",
@@ -347,17 +1629,66 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_developwithayush_go-todo-app_internal_dto.ErrorResponse": {
-            "description": "Standard error response wrapper",
+        "github_com_developwithayush_go-todo-app_internal_dto.JobResponse": {
+            "description": "Response containing a background job",
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Error message"
+                "data": {
+                    "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.JobResponseData"
                 },
                 "success": {
                     "type": "boolean",
-                    "example": false
+                    "example": true
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.JobResponseData": {
+            "description": "Background job. Result holds the import report once status is succeeded.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "done": {
+                    "type": "integer",
+                    "example": 1500
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Job failed"
+                },
+                "finishedAt": {
+                    "type": "string",
+                    "example": "2024-01-15T10:31:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "65a1b2c3d4e5f6a7b8c9d0e1"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "todo_import"
+                },
+                "result": {
+                    "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.ImportReportResponse"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "succeeded",
+                        "failed"
+                    ],
+                    "example": "running"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 4000
+                },
+                "updatedAt": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:05Z"
                 }
             }
         },
@@ -375,6 +1706,48 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_developwithayush_go-todo-app_internal_dto.PatchTodoRequest": {
+            "description": "Merge patch for a todo. Only the fields present are changed; null clears a field.",
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#1e90ff"
+                },
+                "completed": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Milk, eggs, bread, butter"
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 2
+                },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO,WE"
+                },
+                "status": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "in_progress"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Buy groceries (updated)"
+                }
+            }
+        },
         "github_com_developwithayush_go-todo-app_internal_dto.SendOTPRequest": {
             "description": "Request body for sending OTP to user's email",
             "type": "object",
@@ -388,6 +1761,184 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_developwithayush_go-todo-app_internal_dto.StatusChangeResponse": {
+            "description": "Entry in a todo's status history",
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string",
+                    "example": "2024-01-15T11:00:00Z"
+                },
+                "from": {
+                    "type": "string",
+                    "example": "todo"
+                },
+                "to": {
+                    "type": "string",
+                    "example": "in_progress"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.SyncChangesResponse": {
+            "description": "Todos changed and deleted since the given token",
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TombstoneResponse"
+                    }
+                },
+                "hasMore": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "todos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoResponse"
+                    }
+                },
+                "token": {
+                    "type": "string",
+                    "example": "42"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.SyncMutation": {
+            "description": "A create, update or delete made while offline. BaseSeq is the seq of the todo the client last saw.",
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "baseSeq": {
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 12
+                },
+                "completed": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Milk, eggs, bread"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ],
+                    "example": "update"
+                },
+                "status": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "done"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Buy groceries"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.SyncPullResponse": {
+            "description": "Response containing changes since the given token",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.SyncChangesResponse"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.SyncPushRequest": {
+            "description": "Batch of client mutations applied in order. Since is the token of the client's last pull.",
+            "type": "object",
+            "properties": {
+                "mutations": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.SyncMutation"
+                    }
+                },
+                "since": {
+                    "type": "string",
+                    "example": "42"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.SyncPushResponse": {
+            "description": "Response after applying a batch of client mutations",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.SyncPushResult"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.SyncPushResult": {
+            "description": "Per-mutation results and the token to pull from next. Pulling from it returns the applied mutations again, together with anything other devices changed meanwhile.",
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.SyncResultResponse"
+                    }
+                },
+                "token": {
+                    "type": "string",
+                    "example": "42"
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.SyncResultResponse": {
+            "description": "Outcome of one client mutation: applied, conflict or rejected",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "todo changed on the server"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "index": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "op": {
+                    "type": "string",
+                    "example": "update"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "conflict"
+                },
+                "todo": {
+                    "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.TodoResponse"
+                }
+            }
+        },
         "github_com_developwithayush_go-todo-app_internal_dto.TodoCreateResponse": {
             "description": "Response after successfully creating a todo",
             "type": "object",
@@ -417,14 +1968,62 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_developwithayush_go-todo-app_internal_dto.TodoRecord": {
+            "description": "Todo as exported and imported. Only title is required on import; status wins over completed when both are set.",
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "completed": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "completedAt": {
+                    "type": "string",
+                    "example": "2024-01-16T09:00:00Z"
+                },
+                "createdAt": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "example": "Milk, eggs, bread"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "status": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "done"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "Buy groceries"
+                }
+            }
+        },
         "github_com_developwithayush_go-todo-app_internal_dto.TodoResponse": {
             "description": "Todo item response structure",
             "type": "object",
             "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#1e90ff"
+                },
                 "completed": {
                     "type": "boolean",
                     "example": false
                 },
+                "completedAt": {
+                    "type": "string",
+                    "example": "2024-01-16T09:00:00Z"
+                },
                 "createdAt": {
                     "type": "string",
                     "example": "2024-01-15T10:30:00Z"
@@ -433,6 +2032,12 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Milk, eggs, bread"
                 },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_developwithayush_go-todo-app_internal_dto.StatusChangeResponse"
+                    }
+                },
                 "id": {
                     "type": "string",
                     "example": "507f1f77bcf86cd799439011"
@@ -441,6 +2046,22 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 0
                 },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO,WE"
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "status": {
+                    "type": "string",
+                    "example": "in_progress"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
                 "title": {
                     "type": "string",
                     "example": "Buy groceries"
@@ -452,19 +2073,67 @@ const docTemplate = `{
                 "userId": {
                     "type": "string",
                     "example": "507f1f77bcf86cd799439012"
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "github_com_developwithayush_go-todo-app_internal_dto.TombstoneResponse": {
+            "description": "Marker for a todo deleted after the sync token",
+            "type": "object",
+            "properties": {
+                "deletedAt": {
+                    "type": "string",
+                    "example": "2024-01-15T10:30:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "seq": {
+                    "type": "integer",
+                    "example": 13
                 }
             }
         },
         "github_com_developwithayush_go-todo-app_internal_dto.UpdateTodoRequest": {
-            "description": "Request body for updating an existing todo item",
+            "description": "Request body for updating an existing todo item. An empty description, color, recurrence or timezone clears it; omitted optional fields are left unchanged.",
             "type": "object",
+            "required": [
+                "title"
+            ],
             "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "#1e90ff"
+                },
+                "completed": {
+                    "type": "boolean",
+                    "example": true
+                },
                 "description": {
                     "type": "string",
+                    "maxLength": 2000,
                     "example": "Milk, eggs, bread, butter"
                 },
+                "recurrence": {
+                    "type": "string",
+                    "example": "FREQ=WEEKLY;BYDAY=MO,WE"
+                },
+                "status": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "example": "in_progress"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
                 "title": {
                     "type": "string",
+                    "maxLength": 200,
                     "example": "Buy groceries (updated)"
                 }
             }
@@ -508,7 +2177,7 @@ const docTemplate = `{
     },
     "securityDefinitions": {
         "CookieAuth": {
-            "description": "JWT token stored in HTTP-only cookie. Obtain token by verifying OTP at /auth/verify-otp endpoint.",
+            "description": "JWT token stored in HTTP-only cookie. Obtain token by verifying OTP at /auth/verify-otp endpoint. POST, PUT, PATCH and DELETE requests must also send a token from /auth/csrf in the X-CSRF-Token header.",
             "type": "apiKey",
             "name": "todo_app",
             "in": "cookie"
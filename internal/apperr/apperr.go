@@ -0,0 +1,210 @@
+// Package apperr defines the typed errors services and handlers return.
+// Each error has a kind, which decides the HTTP status, and a
+// machine-readable code that clients can switch on. The HTTP layer
+// renders them as RFC 7807 problem details.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// ErrInvalidBody is returned for request bodies that cannot be decoded.
+var ErrInvalidBody = Validation("invalid_body", "Invalid request body")
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindPreconditionFailed
+	KindUnsupportedMediaType
+	KindUnprocessable
+	KindRateLimited
+	KindNotImplemented
+)
+
+// Status returns the HTTP status errors of this kind are sent with.
+func (k Kind) Status() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindNotImplemented:
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError describes one invalid field of a request.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// Error is an error with a kind and a code. Message is shown to the
+// client; Cause is only logged. Extensions are extra members of the
+// problem body, such as the per-item results of a rejected batch.
+type Error struct {
+	Kind       Kind
+	Code       string
+	Message    string
+	Fields     []FieldError
+	Extensions map[string]interface{}
+	Cause      error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+// Internal reports a failure the client cannot fix. The message is sent,
+// the cause is not.
+func Internal(message string, cause error) *Error {
+	return &Error{Kind: KindInternal, Code: "internal", Message: message, Cause: cause}
+}
+
+// InvalidBody is for errors from binding a request body: typed errors,
+// such as validation failures, are returned as they are and anything else
+// becomes ErrInvalidBody.
+func InvalidBody(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return ErrInvalidBody.WithCause(err)
+}
+
+// Wrap returns err unchanged when it is already typed, and an internal
+// error with message otherwise.
+func Wrap(err error, message string) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return Internal(message, err)
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is matches errors of the same kind and code, so a sentinel still
+// matches after WithCause.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// WithCause returns a copy of e that records cause.
+func (e *Error) WithCause(cause error) *Error {
+	c := *e
+	c.Cause = cause
+	return &c
+}
+
+// WithExtension returns a copy of e whose problem body has the extra
+// member key.
+func (e *Error) WithExtension(key string, value interface{}) *Error {
+	c := *e
+	c.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		c.Extensions[k] = v
+	}
+	c.Extensions[key] = value
+	return &c
+}
+
+// Status returns the HTTP status for err: the kind's status for typed
+// errors, the code of Fiber errors such as the router's 404, and 500 for
+// anything else.
+func Status(err error) int {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind.Status()
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// Rendered marks err as already written to the response, as by a
+// middleware that needed the problem body before returning. Error
+// handlers leave the response alone, while logging and metrics still see
+// err and its status.
+func Rendered(err error) error {
+	return &renderedError{err}
+}
+
+// IsRendered reports whether err was marked by Rendered.
+func IsRendered(err error) bool {
+	var r *renderedError
+	return errors.As(err, &r)
+}
+
+type renderedError struct {
+	err error
+}
+
+func (r *renderedError) Error() string {
+	return r.err.Error()
+}
+
+func (r *renderedError) Unwrap() error {
+	return r.err
+}
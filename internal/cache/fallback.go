@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// FallbackStore sends calls to primary while it is healthy and to
+// secondary otherwise. Use it for state that may safely be tracked per
+// node during an outage, such as rate limits; not for caches that other
+// replicas invalidate.
+type FallbackStore struct {
+	primary   Store
+	secondary Store
+}
+
+func NewFallbackStore(primary, secondary Store) *FallbackStore {
+	return &FallbackStore{primary: primary, secondary: secondary}
+}
+
+func (s *FallbackStore) active() Store {
+	if s.primary.Healthy() {
+		return s.primary
+	}
+	return s.secondary
+}
+
+func (s *FallbackStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return s.active().Get(ctx, key)
+}
+
+func (s *FallbackStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.active().Set(ctx, key, value, ttl)
+}
+
+func (s *FallbackStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.active().SetNX(ctx, key, value, ttl)
+}
+
+func (s *FallbackStore) Delete(ctx context.Context, keys ...string) error {
+	return s.active().Delete(ctx, keys...)
+}
+
+func (s *FallbackStore) Incr(ctx context.Context, key string) (int64, error) {
+	return s.active().Incr(ctx, key)
+}
+
+func (s *FallbackStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return s.active().Expire(ctx, key, ttl)
+}
+
+func (s *FallbackStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return s.active().TTL(ctx, key)
+}
+
+func (s *FallbackStore) Healthy() bool {
+	return s.active().Healthy()
+}
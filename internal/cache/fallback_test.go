@@ -0,0 +1,239 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeRedis speaks enough RESP for a RedisStore: PING, GET, SET and DEL.
+// Every other command, including the client's connection handshake, gets
+// an error reply, which go-redis treats as an older server.
+type fakeRedis struct {
+	t    *testing.T
+	addr string
+
+	mu    sync.Mutex
+	ln    net.Listener
+	conns map[net.Conn]struct{}
+	data  map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	f := &fakeRedis{t: t, conns: map[net.Conn]struct{}{}, data: map[string]string{}}
+	f.start("127.0.0.1:0")
+	t.Cleanup(f.stop)
+	return f
+}
+
+func (f *fakeRedis) start(addr string) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		f.t.Fatalf("listen: %v", err)
+	}
+	f.mu.Lock()
+	f.ln, f.addr = ln, ln.Addr().String()
+	f.mu.Unlock()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns[conn] = struct{}{}
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+}
+
+// stop closes the listener and drops every client connection, as a Redis
+// outage would.
+func (f *fakeRedis) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ln != nil {
+		f.ln.Close()
+		f.ln = nil
+	}
+	for conn := range f.conns {
+		conn.Close()
+		delete(f.conns, conn)
+	}
+}
+
+func (f *fakeRedis) get(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	v, ok := f.data[key]
+	return v, ok
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.reply(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) reply(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		v, ok := f.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+	case "SET":
+		f.data[args[1]] = args[2]
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := f.data[key]; ok {
+				delete(f.data, key)
+				n++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	}
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	n, err := readLength(r, '*')
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		size, err := readLength(r, '$')
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func readLength(r *bufio.Reader, prefix byte) (int, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
+
+// fakeStore is a Store whose health the test switches.
+type fakeStore struct {
+	*MemoryStore
+	healthy bool
+}
+
+func (s *fakeStore) Healthy() bool {
+	return s.healthy
+}
+
+func TestFallbackStoreFollowsPrimaryHealth(t *testing.T) {
+	primary := &fakeStore{MemoryStore: NewMemoryStore(10), healthy: true}
+	secondary := NewMemoryStore(10)
+	s := NewFallbackStore(primary, secondary)
+	ctx := context.Background()
+
+	s.Set(ctx, "k", []byte("primary"), 0)
+	primary.healthy = false
+	if _, err := s.Get(ctx, "k"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get while the primary is down: got %v, want a miss from the secondary", err)
+	}
+	s.Set(ctx, "k", []byte("secondary"), 0)
+	if n, _ := s.Incr(ctx, "n"); n != 1 {
+		t.Fatalf("Incr on the secondary = %d, want 1", n)
+	}
+
+	primary.healthy = true
+	if v, err := s.Get(ctx, "k"); err != nil || string(v) != "primary" {
+		t.Fatalf("Get after recovery = %q, %v; want the primary's value", v, err)
+	}
+	if v, _ := secondary.Get(ctx, "k"); string(v) != "secondary" {
+		t.Fatalf("secondary holds %q, want the value written during the outage", v)
+	}
+}
+
+func TestFallbackStoreSurvivesRedisOutage(t *testing.T) {
+	srv := newFakeRedis(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.addr, MaxRetries: -1, DialTimeout: time.Second})
+	primary := NewRedisStore(client, logger.Nop())
+	t.Cleanup(func() { primary.Close() })
+	if !primary.Healthy() {
+		t.Fatal("RedisStore is not healthy with the server up")
+	}
+	local := NewMemoryStore(10)
+	s := NewFallbackStore(primary, local)
+	ctx := context.Background()
+
+	if err := s.Set(ctx, "k", []byte("redis"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if v, ok := srv.get("k"); !ok || v != "redis" {
+		t.Fatalf("redis holds %q, want the value", v)
+	}
+
+	addr := srv.addr
+	srv.stop()
+	// the first call after the outage fails and marks Redis unhealthy
+	if err := s.Set(ctx, "k", []byte("local"), 0); err == nil {
+		t.Fatal("Set to a stopped Redis succeeded")
+	}
+	if primary.Healthy() {
+		t.Fatal("RedisStore is healthy after a connection error")
+	}
+	if err := s.Set(ctx, "k", []byte("local"), 0); err != nil {
+		t.Fatalf("Set during the outage: %v", err)
+	}
+	if v, err := s.Get(ctx, "k"); err != nil || string(v) != "local" {
+		t.Fatalf("Get during the outage = %q, %v; want the local value", v, err)
+	}
+
+	srv.start(addr)
+	deadline := time.Now().Add(5 * time.Second)
+	for !primary.Healthy() {
+		if time.Now().After(deadline) {
+			t.Fatal("RedisStore did not reconnect")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if v, err := s.Get(ctx, "k"); err != nil || string(v) != "redis" {
+		t.Fatalf("Get after recovery = %q, %v; want the value in Redis", v, err)
+	}
+}
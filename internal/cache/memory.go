@@ -0,0 +1,216 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store that evicts the least recently used
+// key once it holds capacity keys. Expired keys are dropped when touched.
+// State is not shared between replicas.
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+	// pinned keys are kept apart from the LRU order and only expire
+	prefixes []string
+	pinned   map[string]*memoryEntry
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		pinned:   make(map[string]*memoryEntry),
+	}
+}
+
+// Pin exempts keys starting with prefix from eviction, for records that
+// must last until they expire, such as background jobs. Pinned keys do
+// not count toward the capacity. Set pins up before using the store.
+func (s *MemoryStore) Pin(prefix string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prefixes = append(s.prefixes, prefix)
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.lookup(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return slices.Clone(e.value), nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store(key, slices.Clone(value), expiry(ttl))
+	return nil
+}
+
+func (s *MemoryStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.lookup(key); ok {
+		return false, nil
+	}
+	s.store(key, slices.Clone(value), expiry(ttl))
+	return true, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := s.items[key]; ok {
+			s.remove(el)
+		}
+		delete(s.pinned, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	var expiresAt time.Time
+	if e, ok := s.lookup(key); ok {
+		v, err := strconv.ParseInt(string(e.value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		n, expiresAt = v, e.expiresAt
+	}
+	n++
+	s.store(key, []byte(strconv.FormatInt(n, 10)), expiresAt)
+	return n, nil
+}
+
+func (s *MemoryStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.lookup(key); ok {
+		e.expiresAt = expiry(ttl)
+	}
+	return nil
+}
+
+func (s *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.lookup(key)
+	if !ok {
+		return 0, ErrMiss
+	}
+	if e.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return time.Until(e.expiresAt), nil
+}
+
+func (s *MemoryStore) Healthy() bool {
+	return true
+}
+
+// lookup returns the live entry for key and marks it recently used.
+// Callers hold s.mu.
+func (s *MemoryStore) lookup(key string) (*memoryEntry, bool) {
+	if e, ok := s.pinned[key]; ok {
+		if e.expired(time.Now()) {
+			delete(s.pinned, key)
+			return nil, false
+		}
+		return e, true
+	}
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*memoryEntry)
+	if e.expired(time.Now()) {
+		s.remove(el)
+		return nil, false
+	}
+	s.order.MoveToFront(el)
+	return e, true
+}
+
+// store writes the entry and evicts the least recently used keys beyond
+// capacity. Callers hold s.mu.
+func (s *MemoryStore) store(key string, value []byte, expiresAt time.Time) {
+	if s.isPinned(key) {
+		s.storePinned(key, value, expiresAt)
+		return
+	}
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*memoryEntry)
+		e.value, e.expiresAt = value, expiresAt
+		s.order.MoveToFront(el)
+		return
+	}
+	s.items[key] = s.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for s.capacity > 0 && s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+}
+
+// storePinned writes a pinned entry. Pinned keys are not evicted, so the
+// expired ones are swept here rather than left until they are touched.
+func (s *MemoryStore) storePinned(key string, value []byte, expiresAt time.Time) {
+	now := time.Now()
+	for k, e := range s.pinned {
+		if e.expired(now) {
+			delete(s.pinned, k)
+		}
+	}
+	s.pinned[key] = &memoryEntry{key: key, value: value, expiresAt: expiresAt}
+}
+
+func (s *MemoryStore) isPinned(key string) bool {
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.items, el.Value.(*memoryEntry).key)
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
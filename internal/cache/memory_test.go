@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewMemoryStore(2)
+	ctx := context.Background()
+	s.Set(ctx, "a", []byte("1"), 0)
+	s.Set(ctx, "b", []byte("2"), 0)
+	// reading a makes b the least recently used key
+	if _, err := s.Get(ctx, "a"); err != nil {
+		t.Fatalf("Get a: %v", err)
+	}
+	s.Set(ctx, "c", []byte("3"), 0)
+
+	if _, err := s.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get b after eviction: got %v, want ErrMiss", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := s.Get(ctx, key); err != nil {
+			t.Fatalf("Get %s: %v", key, err)
+		}
+	}
+
+	// overwriting a key also counts as a use
+	s.Set(ctx, "a", []byte("4"), 0)
+	s.Set(ctx, "d", []byte("5"), 0)
+	if _, err := s.Get(ctx, "c"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get c after eviction: got %v, want ErrMiss", err)
+	}
+	if v, err := s.Get(ctx, "a"); err != nil || string(v) != "4" {
+		t.Fatalf("Get a = %q, %v; want 4", v, err)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	s := NewMemoryStore(0)
+	ctx := context.Background()
+	s.Set(ctx, "short", []byte("x"), 20*time.Millisecond)
+	s.Set(ctx, "forever", []byte("y"), 0)
+
+	if ttl, err := s.TTL(ctx, "short"); err != nil || ttl <= 0 || ttl > 20*time.Millisecond {
+		t.Fatalf("TTL short = %v, %v; want up to 20ms", ttl, err)
+	}
+	if ttl, err := s.TTL(ctx, "forever"); err != nil || ttl != 0 {
+		t.Fatalf("TTL forever = %v, %v; want 0 for no expiry", ttl, err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := s.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get of an expired key: got %v, want ErrMiss", err)
+	}
+	if _, err := s.TTL(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("TTL of an expired key: got %v, want ErrMiss", err)
+	}
+	if ok, err := s.SetNX(ctx, "short", []byte("z"), 0); err != nil || !ok {
+		t.Fatalf("SetNX over an expired key = %v, %v; want true", ok, err)
+	}
+	if v, err := s.Get(ctx, "forever"); err != nil || string(v) != "y" {
+		t.Fatalf("Get forever = %q, %v", v, err)
+	}
+}
+
+func TestMemoryStoreMiss(t *testing.T) {
+	s := NewMemoryStore(10)
+	ctx := context.Background()
+	if _, err := s.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get: got %v, want ErrMiss", err)
+	}
+	if _, err := s.TTL(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("TTL: got %v, want ErrMiss", err)
+	}
+	s.Set(ctx, "gone", []byte("x"), 0)
+	s.Delete(ctx, "gone")
+	if _, err := s.Get(ctx, "gone"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get after Delete: got %v, want ErrMiss", err)
+	}
+}
+
+func TestMemoryStoreIncrKeepsExpiry(t *testing.T) {
+	s := NewMemoryStore(10)
+	ctx := context.Background()
+	if n, err := s.Incr(ctx, "hits"); err != nil || n != 1 {
+		t.Fatalf("Incr of a new key = %d, %v; want 1", n, err)
+	}
+	s.Expire(ctx, "hits", time.Minute)
+	if n, err := s.Incr(ctx, "hits"); err != nil || n != 2 {
+		t.Fatalf("Incr = %d, %v; want 2", n, err)
+	}
+	if ttl, err := s.TTL(ctx, "hits"); err != nil || ttl <= 0 {
+		t.Fatalf("TTL after Incr = %v, %v; want the expiry kept", ttl, err)
+	}
+}
+
+func TestMemoryStoreReturnsCopies(t *testing.T) {
+	s := NewMemoryStore(10)
+	ctx := context.Background()
+	value := []byte("abc")
+	s.Set(ctx, "k", value, 0)
+	value[0] = 'x'
+	got, _ := s.Get(ctx, "k")
+	got[1] = 'y'
+	if again, _ := s.Get(ctx, "k"); string(again) != "abc" {
+		t.Fatalf("stored value = %q, want abc unchanged by callers", again)
+	}
+}
+
+func TestMemoryStorePinnedKeysAreNotEvicted(t *testing.T) {
+	s := NewMemoryStore(2)
+	s.Pin("job:")
+	ctx := context.Background()
+	s.Set(ctx, "job:1", []byte("running"), time.Hour)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		s.Set(ctx, key, []byte("x"), 0)
+	}
+
+	if v, err := s.Get(ctx, "job:1"); err != nil || string(v) != "running" {
+		t.Fatalf("Get job:1 = %q, %v; want the pinned value", v, err)
+	}
+	// pinned keys leave the whole capacity to the others
+	for _, key := range []string{"c", "d"} {
+		if _, err := s.Get(ctx, key); err != nil {
+			t.Fatalf("Get %s: %v", key, err)
+		}
+	}
+
+	s.Set(ctx, "job:2", []byte("done"), 20*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := s.Get(ctx, "job:2"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get of an expired pinned key: got %v, want ErrMiss", err)
+	}
+	s.Delete(ctx, "job:1")
+	if _, err := s.Get(ctx, "job:1"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("Get after Delete: got %v, want ErrMiss", err)
+	}
+}
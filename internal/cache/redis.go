@@ -2,24 +2,211 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/developwithayush/go-todo-app/internal/config"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
-var Client *redis.Client = nil
+const (
+	healthCheckInterval = 5 * time.Second
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// RedisStore is a Store backed by Redis. A background check pings Redis and
+// marks the store unhealthy when it cannot be reached; while unhealthy,
+// calls fail fast with ErrUnavailable and the check retries with
+// exponential backoff until Redis is back.
+type RedisStore struct {
+	client  *redis.Client
+	logr    logger.Logger
+	healthy atomic.Bool
+	wake    chan struct{}
+	stop    chan struct{}
+	once    sync.Once
+}
 
-func InitRedis(cfg *config.Config, logr logger.Logger) error {
-	Client = redis.NewClient(&redis.Options{
+// NewRedisClient returns a client traced with OpenTelemetry. Command
+// arguments are left out of spans since they hold cached bodies and
+// cookies, and the health check pings are not traced.
+func NewRedisClient(cfg *config.Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
 		Addr:     cfg.RedisURI,
 		Password: cfg.RedisPass,
 		DB:       cfg.RedisDB,
 	})
+	err := redisotel.InstrumentTracing(client,
+		redisotel.WithDBStatement(false),
+		redisotel.WithCommandFilter(func(cmd redis.Cmder) bool { return cmd.Name() == "ping" }),
+	)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// NewRedisStore pings Redis once and starts the health check. It returns
+// a usable store even when Redis is down; it reconnects in the background.
+func NewRedisStore(client *redis.Client, logr logger.Logger) *RedisStore {
+	s := &RedisStore{
+		client: client,
+		logr:   logr,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		logr.Error("failed to connect redis", logger.Field("error", err))
+	} else {
+		s.healthy.Store(true)
+		logr.Info("Connected to Redis", logger.Field("uri", client.Options().Addr))
+	}
+
+	go s.monitor()
+	return s
+}
+
+// Client returns the underlying client for features that need more than
+// the Store primitives.
+func (s *RedisStore) Client() *redis.Client {
+	return s.client
+}
+
+func (s *RedisStore) Healthy() bool {
+	return s.healthy.Load()
+}
+
+// Close stops the health check and closes the client.
+func (s *RedisStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return s.client.Close()
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if !s.Healthy() {
+		return nil, ErrUnavailable
+	}
+	v, err := s.client.Get(ctx, key).Bytes()
+	return v, s.check(err)
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if !s.Healthy() {
+		return ErrUnavailable
+	}
+	return s.check(s.client.Set(ctx, key, value, ttl).Err())
+}
 
-	if err := Client.Ping(context.Background()).Err(); err != nil {
+func (s *RedisStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if !s.Healthy() {
+		return false, ErrUnavailable
+	}
+	ok, err := s.client.SetNX(ctx, key, value, ttl).Result()
+	return ok, s.check(err)
+}
+
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if !s.Healthy() {
+		return ErrUnavailable
+	}
+	return s.check(s.client.Del(ctx, keys...).Err())
+}
+
+func (s *RedisStore) Incr(ctx context.Context, key string) (int64, error) {
+	if !s.Healthy() {
+		return 0, ErrUnavailable
+	}
+	n, err := s.client.Incr(ctx, key).Result()
+	return n, s.check(err)
+}
+
+func (s *RedisStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if !s.Healthy() {
+		return ErrUnavailable
+	}
+	return s.check(s.client.Expire(ctx, key, ttl).Err())
+}
+
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if !s.Healthy() {
+		return 0, ErrUnavailable
+	}
+	ttl, err := s.client.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, s.check(err)
+	}
+	switch ttl {
+	case -2:
+		return 0, ErrMiss
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// check maps redis.Nil to ErrMiss and marks the store unhealthy on
+// connection errors so that later calls fail fast until the health check
+// succeeds again. Errors replied by the server leave it healthy.
+func (s *RedisStore) check(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, redis.Nil) {
+		return ErrMiss
+	}
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return err
 	}
+	if s.healthy.CompareAndSwap(true, false) {
+		s.logr.Warn("redis unavailable", logger.Field("error", err))
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
 
-	logr.Info("Connected to Redis", logger.Field("uri", cfg.RedisURI))
-	return nil
+func (s *RedisStore) monitor() {
+	backoff := minReconnectBackoff
+	for {
+		wait := healthCheckInterval
+		if !s.Healthy() {
+			wait = backoff
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+			backoff = minReconnectBackoff
+			continue
+		case <-time.After(wait):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := s.client.Ping(ctx).Err()
+		cancel()
+
+		switch {
+		case err == nil:
+			backoff = minReconnectBackoff
+			if s.healthy.CompareAndSwap(false, true) {
+				s.logr.Info("redis reconnected")
+			}
+		case s.healthy.CompareAndSwap(true, false):
+			s.logr.Warn("redis unavailable", logger.Field("error", err))
+		default:
+			backoff = min(backoff*2, maxReconnectBackoff)
+		}
+	}
 }
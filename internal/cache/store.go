@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMiss is returned when a key does not exist or has expired.
+	ErrMiss = errors.New("cache: key not found")
+	// ErrUnavailable is returned without contacting the backend while it
+	// is marked unhealthy.
+	ErrUnavailable = errors.New("cache: store unavailable")
+)
+
+// Store is a key-value store with expiry. Features that keep shared state
+// (response replay, rate limits, cached lists) use it so they work with
+// Redis across replicas or in process on a single node.
+type Store interface {
+	// Get returns the value stored at key or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value at key. A zero ttl means no expiry.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX stores value only when key does not exist and reports whether
+	// it did. It doubles as a lock: the caller that gets true holds it
+	// until it deletes the key or the ttl runs out.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, keys ...string) error
+	// Incr adds one to the integer at key, starting from zero, and
+	// returns the new value. An existing expiry is kept.
+	Incr(ctx context.Context, key string) (int64, error)
+	// Expire sets the key's time to live.
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// TTL returns the key's remaining time to live, 0 when it has no
+	// expiry, or ErrMiss.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Healthy reports whether the store is currently reachable.
+	Healthy() bool
+}
@@ -1,58 +1,162 @@
 package config
 
-import (
-	"fmt"
-	"os"
-)
+import "time"
 
+// Config is the application configuration. Every field has a key used in
+// config files and as a flag name (with dashes), and an env variable that
+// overrides the file. See Load for the order the sources are applied in.
 type Config struct {
-	Port       string
-	Env        string
-	MongoURI   string
-	MongoDB    string
-	RedisURI   string
-	RedisPass  string
-	RedisDB    int
-	JWTSecret  string
-	CookieName string
-	SMTPHost   string
-	SMTPPort   string
-	SMTPUser   string
-	SMTPPass   string
+	Port       int    `key:"port" env:"PORT" validate:"min=1,max=65535"`
+	Env        string `key:"env" env:"ENV" validate:"oneof=development|dev|test|staging|production|prod"`
+	LogLevel   string `key:"log_level" env:"LOG_LEVEL" validate:"oneof=|debug|info|warn|error"`
+	MongoURI   string `key:"mongo_uri" env:"MONGO_URI" secret:"uri"`
+	MongoDB    string `key:"mongo_db" env:"MONGO_DB"`
+	RedisURI   string `key:"redis_uri" env:"REDIS_URI"`
+	RedisPass  string `key:"redis_pass" env:"REDIS_PASS" secret:"true"`
+	RedisDB    int    `key:"redis_db" env:"REDIS_DB" validate:"min=0,max=15"`
+	JWTSecret  string `key:"jwt_secret" env:"JWT_SECRET" validate:"required" secret:"true"`
+	CookieName string `key:"cookie_name" env:"COOKIE_NAME" validate:"required"`
+	// CSRFCookieName is the cookie holding the CSRF token that
+	// cookie-authenticated requests must echo in X-CSRF-Token.
+	CSRFCookieName string `key:"csrf_cookie_name" env:"CSRF_COOKIE_NAME" validate:"required"`
+	SMTPHost       string `key:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort       int    `key:"smtp_port" env:"SMTP_PORT" validate:"min=1,max=65535"`
+	SMTPUser       string `key:"smtp_user" env:"SMTP_USER"`
+	SMTPPass       string `key:"smtp_pass" env:"SMTP_PASS" secret:"true"`
+
+	// AdminPort serves /metrics and the health probes on a separate
+	// listener that is not exposed publicly. 0 serves /metrics on Port.
+	AdminPort int `key:"admin_port" env:"ADMIN_PORT" validate:"min=0,max=65535"`
+
+	// TodoStatuses is the ordered workflow. The initial and done statuses
+	// must be in it; left empty they are its first and last status. It
+	// applies to every todo of every user; there are no per-project
+	// workflows.
+	TodoStatuses      []string `key:"todo_statuses" env:"TODO_STATUSES" validate:"required"`
+	TodoInitialStatus string   `key:"todo_initial_status" env:"TODO_INITIAL_STATUS"`
+	TodoDoneStatuses  []string `key:"todo_done_statuses" env:"TODO_DONE_STATUSES"`
+
+	// StorageDriver selects the database: mongo, postgres, sqlite, or
+	// memory, which keeps nothing across restarts.
+	// DatabaseURL is the connection string for the SQL drivers.
+	StorageDriver string `key:"storage_driver" env:"STORAGE_DRIVER" validate:"oneof=mongo|postgres|sqlite|memory"`
+	DatabaseURL   string `key:"database_url" env:"DATABASE_URL" secret:"uri"`
+
+	// MigrateOnStart applies pending schema migrations when the server
+	// starts. Turn it off to run them with the migrate command instead.
+	MigrateOnStart bool `key:"migrate_on_start" env:"MIGRATE_ON_START"`
+
+	// ListCacheTTL is how long todo lists stay cached. 0 disables the
+	// cache.
+	ListCacheTTL time.Duration `key:"list_cache_ttl" env:"LIST_CACHE_TTL" validate:"min=0"`
+
+	// CacheDriver selects the store behind caching, idempotency and rate
+	// limits: redis, or memory for single-node deployments without Redis.
+	// CacheSize caps the number of keys the memory store keeps.
+	CacheDriver string `key:"cache_driver" env:"CACHE_DRIVER" validate:"oneof=redis|memory"`
+	CacheSize   int    `key:"cache_size" env:"CACHE_SIZE" validate:"min=1"`
+
+	// TraceExporter selects where OpenTelemetry spans go: none, stdout or
+	// otlp. The OTLP exporter sends over HTTP to OTLPEndpoint, or to the
+	// standard OTEL_EXPORTER_OTLP_* settings when it is empty.
+	// TraceSampleRatio is the share of new traces recorded; incoming
+	// requests follow the caller's sampling decision.
+	ServiceName      string  `key:"service_name" env:"SERVICE_NAME" validate:"required"`
+	TraceExporter    string  `key:"trace_exporter" env:"TRACE_EXPORTER" validate:"oneof=none|stdout|otlp"`
+	OTLPEndpoint     string  `key:"otlp_endpoint" env:"OTLP_ENDPOINT"`
+	TraceSampleRatio float64 `key:"trace_sample_ratio" env:"TRACE_SAMPLE_RATIO" validate:"min=0,max=1"`
+
+	// ShutdownTimeout bounds how long a stopping server waits for in-flight
+	// requests before closing connections and clients. ShutdownDelay keeps
+	// serving for a while after /readyz turns not ready, so load balancers
+	// notice before the listener closes.
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" validate:"min=1"`
+	ShutdownDelay   time.Duration `key:"shutdown_delay" env:"SHUTDOWN_DELAY" validate:"min=0"`
+
+	// RateLimits holds the limit of each route group. Groups without an
+	// entry use "default", which must be set. Groups set from a file, env
+	// or flag replace only those groups of the defaults below.
+	RateLimits RateLimits `key:"rate_limits" env:"RATE_LIMITS"`
+
+	// CORSOrigins lists the origins browsers may call the API from, such
+	// as https://app.example.com. https://*.example.com allows any
+	// subdomain and * any origin, which cannot be combined with
+	// CORSCredentials. CORSMaxAge is how long preflight results are cached.
+	CORSOrigins     []string      `key:"cors_origins" env:"CORS_ORIGINS"`
+	CORSMethods     []string      `key:"cors_methods" env:"CORS_METHODS" validate:"required"`
+	CORSHeaders     []string      `key:"cors_headers" env:"CORS_HEADERS"`
+	CORSMaxAge      time.Duration `key:"cors_max_age" env:"CORS_MAX_AGE" validate:"min=0"`
+	CORSCredentials bool          `key:"cors_credentials" env:"CORS_CREDENTIALS"`
+
+	// SecurityHeaders selects the security header profile: strict adds
+	// HSTS and is the default in production and staging, relaxed is the
+	// default elsewhere, and off sends none.
+	SecurityHeaders string `key:"security_headers" env:"SECURITY_HEADERS" validate:"oneof=|strict|relaxed|off"`
 }
 
-func Load() *Config {
+// Default returns the configuration used for anything no source sets.
+func Default() *Config {
 	return &Config{
-		Port:       get("PORT", "5000"),
-		Env:        get("ENV", "development"),
-		MongoURI:   get("MONGO_URI", "mongodb://localhost:27017"),
-		MongoDB:    get("MONGO_DB", "todo_app"),
-		RedisURI:   get("REDIS_URI", "localhost:6379"),
-		RedisPass:  get("REDIS_PASS", ""),
-		RedisDB:    getInt("REDIS_DB", 0),
-		JWTSecret:  get("JWT_SECRET", "secret"),
-		CookieName: get("COOKIE_NAME", "todo_app"),
-		SMTPHost:   get("SMTP_HOST", "smtp.gmail.com"),
-		SMTPPort:   get("SMTP_PORT", "587"),
-		SMTPUser:   get("SMTP_USER", ""),
-		SMTPPass:   get("SMTP_PASS", ""),
+		Port:           5000,
+		Env:            "development",
+		MongoURI:       "mongodb://localhost:27017",
+		MongoDB:        "todo_app",
+		RedisURI:       "localhost:6379",
+		RedisDB:        0,
+		JWTSecret:      defaultJWTSecret,
+		CookieName:     "todo_app",
+		CSRFCookieName: "todo_app_csrf",
+		SMTPHost:       "smtp.gmail.com",
+		SMTPPort:       587,
+
+		TodoStatuses:      []string{"backlog", "todo", "in_progress", "blocked", "done", "cancelled"},
+		TodoInitialStatus: "todo",
+		TodoDoneStatuses:  []string{"done"},
+
+		StorageDriver: "mongo",
+		DatabaseURL:   "file:todo.db",
+
+		MigrateOnStart: true,
+
+		ListCacheTTL: 5 * time.Minute,
+
+		CacheDriver: "redis",
+		CacheSize:   10000,
+
+		ServiceName:      "go-todo-app",
+		TraceExporter:    "none",
+		TraceSampleRatio: 1,
+
+		ShutdownTimeout: 30 * time.Second,
+
+		RateLimits: RateLimits{
+			"auth":    {Limit: 10, Window: time.Minute},
+			"todos":   {Limit: 300, Window: time.Minute},
+			"sync":    {Limit: 60, Window: time.Minute},
+			"default": {Limit: 600, Window: time.Minute},
+		},
+
+		CORSOrigins:     []string{"http://localhost:3000"},
+		CORSMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		CORSHeaders:     []string{"Content-Type", "Authorization", "If-Match", "If-None-Match", "Idempotency-Key", "X-Request-ID", "X-CSRF-Token"},
+		CORSMaxAge:      10 * time.Minute,
+		CORSCredentials: true,
 	}
 }
 
-func get(key, def string) string {
-	if v, ok := os.LookupEnv(key); ok {
-		return v
-	}
-	return def
+// IsProduction reports whether the app runs in a production environment.
+func (c *Config) IsProduction() bool {
+	return c.Env == "production" || c.Env == "prod"
 }
 
-func getInt(key string, def int) int {
-	if v, ok := os.LookupEnv(key); ok {
-		var n int
-		_, err := fmt.Sscanf(v, "%d", &n)
-		if err == nil {
-			return n
-		}
+// SecurityProfile returns the security header profile to use, picking one
+// by environment when none is configured.
+func (c *Config) SecurityProfile() string {
+	switch {
+	case c.SecurityHeaders != "":
+		return c.SecurityHeaders
+	case c.IsProduction() || c.Env == "staging":
+		return "strict"
 	}
-	return def
+	return "relaxed"
 }
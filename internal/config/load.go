@@ -0,0 +1,235 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v3"
+)
+
+const defaultJWTSecret = "secret"
+
+// field is a settable Config field together with its tags.
+type field struct {
+	key   string
+	env   string
+	value reflect.Value
+	tag   reflect.StructTag
+}
+
+func (c *Config) fields() []field {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	fields := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fields = append(fields, field{
+			key:   sf.Tag.Get("key"),
+			env:   sf.Tag.Get("env"),
+			value: v.Field(i),
+			tag:   sf.Tag,
+		})
+	}
+	return fields
+}
+
+// Load builds the configuration from, in increasing priority: defaults, the
+// YAML or TOML file named by -config or CONFIG_FILE, env variables and
+// command line flags. args are the command line arguments without the
+// program name; the positional ones left after the flags are returned.
+//
+// Every problem found is reported in one joined error. The config is
+// returned even then, so callers can still show what was loaded.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+	fields := cfg.fields()
+
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	flags := map[string]string{}
+	for _, f := range fields {
+		name := strings.ReplaceAll(f.key, "_", "-")
+		usage := "sets " + f.key + " (env " + f.env + ")"
+		set := func(s string) error {
+			flags[f.key] = s
+			return nil
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, usage, set)
+		} else {
+			fs.Func(name, usage, set)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+
+	var errs []error
+	if *file != "" {
+		values, err := readFile(*file)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		byKey := map[string]field{}
+		for _, f := range fields {
+			byKey[f.key] = f
+		}
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			f, ok := byKey[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown key %q", *file, key))
+				continue
+			}
+			if err := setValue(f.value, values[key]); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", *file, key, err))
+			}
+		}
+	}
+	for _, f := range fields {
+		if s, ok := os.LookupEnv(f.env); ok {
+			if err := setString(f.value, s); err != nil {
+				errs = append(errs, fmt.Errorf("env %s: %w", f.env, err))
+			}
+		}
+	}
+	for _, f := range fields {
+		if s, ok := flags[f.key]; ok {
+			if err := setString(f.value, s); err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", strings.ReplaceAll(f.key, "_", "-"), err))
+			}
+		}
+	}
+
+	errs = append(errs, cfg.validate()...)
+	return cfg, fs.Args(), errors.Join(errs...)
+}
+
+// readFile decodes a config file by extension. Nested tables are
+// flattened, so "redis: {uri: ...}" sets redis_uri.
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := map[string]any{}
+	flatten(values, "", raw)
+	return values, nil
+}
+
+func flatten(dst map[string]any, prefix string, src map[string]any) {
+	for k, v := range src {
+		key := strings.ToLower(prefix + k)
+		// rate limits may be written as a table of group: "limit/window"
+		if nested, ok := v.(map[string]any); ok && key != "rate_limits" {
+			flatten(dst, key+"_", nested)
+			continue
+		}
+		dst[key] = v
+	}
+}
+
+// setValue stores a value decoded from a config file.
+func setValue(v reflect.Value, raw any) error {
+	switch raw := raw.(type) {
+	case []any:
+		parts := make([]string, len(raw))
+		for i, item := range raw {
+			parts[i] = fmt.Sprint(item)
+		}
+		return setString(v, strings.Join(parts, ","))
+	case map[string]any:
+		parts := make([]string, 0, len(raw))
+		for k, item := range raw {
+			parts = append(parts, k+"="+fmt.Sprint(item))
+		}
+		return setString(v, strings.Join(parts, ","))
+	case nil:
+		return nil
+	}
+	return setString(v, fmt.Sprint(raw))
+}
+
+// setString parses s into v according to v's type. Lists are comma
+// separated, and a duration without a unit is read as seconds.
+func setString(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	s = strings.TrimSpace(s)
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			v.SetInt(n * int64(time.Second))
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		list := []string{}
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const redacted = "******"
+
+var dsnPassword = regexp.MustCompile(`(?i)(password=)\S+`)
+
+// Print writes the configuration with secrets redacted, as YAML that can
+// be used as a config file, or as env assignments when format is "env".
+func (c *Config) Print(w io.Writer, format string) error {
+	switch format {
+	case "yaml", "":
+		doc := &yaml.Node{Kind: yaml.MappingNode}
+		for _, f := range c.fields() {
+			doc.Content = append(doc.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: f.key},
+				valueNode(f))
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	case "env":
+		for _, f := range c.fields() {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", f.env, quoteEnv(display(f))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown format %q, want yaml or env", format)
+}
+
+func valueNode(f field) *yaml.Node {
+	if f.value.Kind() == reflect.Slice {
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < f.value.Len(); i++ {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.value.Index(i).String()})
+		}
+		return seq
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: display(f)}
+	if f.value.Kind() == reflect.String || f.value.Type() == reflect.TypeOf(time.Duration(0)) {
+		node.Tag = "!!str"
+	}
+	return node
+}
+
+// display formats a field's value the way Load reads it back, with
+// secrets redacted.
+func display(f field) string {
+	var s string
+	switch v := f.value.Interface().(type) {
+	case encoding.TextMarshaler:
+		b, _ := v.MarshalText()
+		s = string(b)
+	case []string:
+		s = strings.Join(v, ",")
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	switch f.tag.Get("secret") {
+	case "true":
+		if s != "" {
+			s = redacted
+		}
+	case "uri":
+		s = redactURI(s)
+	}
+	return s
+}
+
+// redactURI hides the password of a connection string, written either as
+// a URL or as key=value pairs.
+func redactURI(s string) string {
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(s, "${1}"+redacted)
+}
+
+func quoteEnv(s string) string {
+	if strings.ContainsAny(s, " \t\n\"'$`\\#") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit allows Limit requests per Window. A limit of 0 turns limiting
+// off.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimits maps route groups to their limit. As text it is written as
+// "group=limit/window" separated by commas, for example
+// "auth=10/1m,todos=300/1m".
+type RateLimits map[string]RateLimit
+
+// UnmarshalText sets the groups named in text and keeps the limits already
+// loaded for the others, so "auth=5/1m" tightens auth and leaves default
+// and the remaining groups as they were.
+func (r *RateLimits) UnmarshalText(text []byte) error {
+	rules := make(RateLimits, len(*r))
+	for group, limit := range *r {
+		rules[group] = limit
+	}
+	for _, part := range strings.Split(string(text), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		group, spec, ok := strings.Cut(part, "=")
+		limit, window, ok2 := strings.Cut(spec, "/")
+		if !ok || !ok2 {
+			return fmt.Errorf("rate limit %q: want group=limit/window", part)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return fmt.Errorf("rate limit %q: invalid limit", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(window))
+		if err != nil || d < time.Second {
+			return fmt.Errorf("rate limit %q: window must be at least 1s", part)
+		}
+		rules[strings.TrimSpace(group)] = RateLimit{Limit: n, Window: d}
+	}
+	*r = rules
+	return nil
+}
+
+func (r RateLimits) MarshalText() ([]byte, error) {
+	groups := make([]string, 0, len(r))
+	for group := range r {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	parts := make([]string, len(groups))
+	for i, group := range groups {
+		parts[i] = group + "=" + strconv.Itoa(r[group].Limit) + "/" + r[group].Window.String()
+	}
+	return []byte(strings.Join(parts, ",")), nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitsOverrideDefaults(t *testing.T) {
+	t.Setenv("RATE_LIMITS", "auth=5/1m, export=2/10s")
+	cfg, _, err := Load(nil)
+	if err != nil && strings.Contains(err.Error(), "rate_limits") {
+		t.Fatalf("Load: %v", err)
+	}
+
+	want := RateLimits{
+		"auth":    {Limit: 5, Window: time.Minute},
+		"export":  {Limit: 2, Window: 10 * time.Second},
+		"todos":   Default().RateLimits["todos"],
+		"sync":    Default().RateLimits["sync"],
+		"default": Default().RateLimits["default"],
+	}
+	if len(cfg.RateLimits) != len(want) {
+		t.Fatalf("RateLimits = %v, want %v", cfg.RateLimits, want)
+	}
+	for group, limit := range want {
+		if cfg.RateLimits[group] != limit {
+			t.Errorf("RateLimits[%s] = %v, want %v", group, cfg.RateLimits[group], limit)
+		}
+	}
+}
+
+func TestRateLimitsLaterSourcesMerge(t *testing.T) {
+	limits := Default().RateLimits
+	if err := limits.UnmarshalText([]byte("auth=5/1m")); err != nil {
+		t.Fatal(err)
+	}
+	if err := limits.UnmarshalText([]byte("default=100/1m")); err != nil {
+		t.Fatal(err)
+	}
+	if limits["auth"].Limit != 5 || limits["default"].Limit != 100 {
+		t.Fatalf("RateLimits = %v, want auth from the first source and default from the second", limits)
+	}
+	if Default().RateLimits["default"].Limit != 600 {
+		t.Fatal("unmarshalling changed the defaults")
+	}
+}
+
+func TestRateLimitsInvalid(t *testing.T) {
+	for _, text := range []string{"auth", "auth=5", "auth=x/1m", "auth=-1/1m", "auth=5/100ms", "auth=5/forever"} {
+		limits := Default().RateLimits
+		if err := limits.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("UnmarshalText(%q) accepted", text)
+		}
+	}
+}
+
+func TestValidateRequiresDefaultRateLimit(t *testing.T) {
+	cfg := Default()
+	delete(cfg.RateLimits, "default")
+
+	var found bool
+	for _, err := range cfg.validate() {
+		found = found || strings.Contains(err.Error(), "rate_limits")
+	}
+	if !found {
+		t.Fatal("validate accepted rate limits without a default entry")
+	}
+}
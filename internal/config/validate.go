@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validate checks the validate tags of every field and the rules that
+// span several fields.
+func (c *Config) validate() []error {
+	var errs []error
+	for _, f := range c.fields() {
+		for _, rule := range strings.Split(f.tag.Get("validate"), ",") {
+			if rule == "" {
+				continue
+			}
+			if err := checkRule(f.value, rule); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+			}
+		}
+	}
+
+	switch c.StorageDriver {
+	case "mongo":
+		if c.MongoURI == "" || c.MongoDB == "" {
+			errs = append(errs, fmt.Errorf("mongo_uri and mongo_db are required with storage_driver mongo"))
+		}
+	case "postgres", "sqlite":
+		if c.DatabaseURL == "" {
+			errs = append(errs, fmt.Errorf("database_url is required with storage_driver %s", c.StorageDriver))
+		}
+	}
+	if c.CacheDriver == "redis" && c.RedisURI == "" {
+		errs = append(errs, fmt.Errorf("redis_uri is required with cache_driver redis"))
+	}
+
+	if c.TodoInitialStatus != "" && !slices.Contains(c.TodoStatuses, c.TodoInitialStatus) {
+		errs = append(errs, fmt.Errorf("todo_initial_status: %q is not in todo_statuses", c.TodoInitialStatus))
+	}
+	for _, s := range c.TodoDoneStatuses {
+		if !slices.Contains(c.TodoStatuses, s) {
+			errs = append(errs, fmt.Errorf("todo_done_statuses: %q is not in todo_statuses", s))
+		}
+	}
+
+	if _, ok := c.RateLimits["default"]; !ok {
+		errs = append(errs, fmt.Errorf("rate_limits: a default entry is required"))
+	}
+
+	if c.AdminPort != 0 && c.AdminPort == c.Port {
+		errs = append(errs, fmt.Errorf("admin_port must differ from port"))
+	}
+
+	if c.CSRFCookieName == c.CookieName {
+		errs = append(errs, fmt.Errorf("csrf_cookie_name must differ from cookie_name"))
+	}
+
+	for _, origin := range c.CORSOrigins {
+		if err := checkOrigin(origin); err != nil {
+			errs = append(errs, fmt.Errorf("cors_origins: %q %w", origin, err))
+		}
+	}
+	if c.CORSCredentials && slices.Contains(c.CORSOrigins, "*") {
+		errs = append(errs, fmt.Errorf("cors_origins: * cannot be used with cors_credentials"))
+	}
+
+	if c.IsProduction() && c.JWTSecret == defaultJWTSecret {
+		errs = append(errs, fmt.Errorf("jwt_secret: the default secret cannot be used in production"))
+	}
+	return errs
+}
+
+// checkRule applies one validate rule: required, min=N, max=N or
+// oneof=a|b|c. min and max take whole seconds for durations.
+func checkRule(v reflect.Value, rule string) error {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if v.IsZero() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			return fmt.Errorf("is required")
+		}
+	case "oneof":
+		allowed := strings.Split(arg, "|")
+		if !slices.Contains(allowed, v.String()) {
+			return fmt.Errorf("%q must be one of %s", v.String(), strings.Join(allowed, ", "))
+		}
+	case "min", "max":
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("bad rule %q", rule)
+		}
+		limit := arg
+		var got float64
+		switch {
+		case v.Type() == reflect.TypeOf(time.Duration(0)):
+			got, n = float64(v.Int()), n*float64(time.Second)
+			limit = time.Duration(n).String()
+		case v.Kind() == reflect.Float64:
+			got = v.Float()
+		default:
+			got = float64(v.Int())
+		}
+		if name == "min" && got < n {
+			return fmt.Errorf("must be at least %s", limit)
+		}
+		if name == "max" && got > n {
+			return fmt.Errorf("must be at most %s", limit)
+		}
+	default:
+		return fmt.Errorf("unknown rule %q", rule)
+	}
+	return nil
+}
+
+// checkOrigin accepts *, or scheme://host[:port] where the host may start
+// with *. to match its subdomains.
+func checkOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return fmt.Errorf("must start with http:// or https://")
+	}
+	host = strings.TrimPrefix(host, "*.")
+	u, err := url.Parse(scheme + "://" + host)
+	if err != nil || u.Host == "" || u.Host != host || strings.Contains(host, "*") {
+		return fmt.Errorf("must be scheme://host[:port] without a path")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package dbtest connects tests to the storage backends. Backends that
+// need a server are skipped unless their URI is set in the environment.
+package dbtest
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoURIEnv names the variable holding the MongoDB test server's URI.
+// Transactions need it to be a replica set.
+const MongoURIEnv = "TEST_MONGO_URI"
+
+// Mongo returns a fresh database on the MongoDB test server, dropped when
+// the test ends, or skips the test when MongoURIEnv is not set.
+func Mongo(t testing.TB) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv(MongoURIEnv)
+	if uri == "" {
+		t.Skip(MongoURIEnv + " is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+
+	database := client.Database("test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return database
+}
+
+// PostgresURLEnv names the variable holding the Postgres test server's URL.
+const PostgresURLEnv = "TEST_POSTGRES_URL"
+
+// SQLite returns a migrated SQLite database in a temporary file, closed
+// when the test ends.
+func SQLite(t testing.TB) *db.SQL {
+	t.Helper()
+	return openSQL(t, &config.Config{
+		StorageDriver: db.DriverSQLite,
+		DatabaseURL:   "file:" + filepath.Join(t.TempDir(), "test.db"),
+	})
+}
+
+// Postgres returns a migrated schema of its own on the Postgres test
+// server, dropped when the test ends, or skips the test when
+// PostgresURLEnv is not set.
+func Postgres(t testing.TB) *db.SQL {
+	t.Helper()
+	raw := os.Getenv(PostgresURLEnv)
+	if raw == "" {
+		t.Skip(PostgresURLEnv + " is not set")
+	}
+
+	admin := openSQL(t, &config.Config{StorageDriver: db.DriverPostgres, DatabaseURL: raw})
+	schema := "test_" + primitive.NewObjectID().Hex()
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`) })
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %s: %v", PostgresURLEnv, err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return openSQL(t, &config.Config{StorageDriver: db.DriverPostgres, DatabaseURL: u.String()})
+}
+
+func openSQL(t testing.TB, cfg *config.Config) *db.SQL {
+	t.Helper()
+	database, err := db.OpenSQL(cfg, logger.Nop())
+	if err != nil {
+		t.Fatalf("open %s: %v", cfg.StorageDriver, err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := database.Migrate(ctx); err != nil {
+		t.Fatalf("migrate %s: %v", cfg.StorageDriver, err)
+	}
+	return database
+}
@@ -0,0 +1,41 @@
+CREATE TABLE users (
+    id             VARCHAR(24) PRIMARY KEY,
+    email          VARCHAR(320) NOT NULL UNIQUE,
+    otp_hash       TEXT NOT NULL DEFAULT '',
+    otp_expires_at TIMESTAMP NOT NULL,
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL
+);
+
+CREATE TABLE todos (
+    id           VARCHAR(24) PRIMARY KEY,
+    user_id      VARCHAR(24) NOT NULL,
+    title        TEXT NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    status       VARCHAR(64) NOT NULL,
+    completed    BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_at TIMESTAMP NULL,
+    history      TEXT NOT NULL DEFAULT '[]',
+    position     INTEGER NOT NULL DEFAULT 0,
+    version      BIGINT NOT NULL DEFAULT 1,
+    seq          BIGINT NOT NULL DEFAULT 0,
+    created_at   TIMESTAMP NOT NULL,
+    updated_at   TIMESTAMP NOT NULL
+);
+
+CREATE INDEX todos_user_position ON todos (user_id, position);
+CREATE INDEX todos_user_seq ON todos (user_id, seq);
+
+CREATE TABLE todo_tombstones (
+    id         VARCHAR(24) PRIMARY KEY,
+    user_id    VARCHAR(24) NOT NULL,
+    seq        BIGINT NOT NULL,
+    deleted_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX todo_tombstones_user_seq ON todo_tombstones (user_id, seq);
+
+CREATE TABLE counters (
+    id  VARCHAR(64) PRIMARY KEY,
+    seq BIGINT NOT NULL
+);
@@ -0,0 +1,18 @@
+-- Tombstones are keyed by todo and user, so a delete can only ever
+-- replace its own user's tombstone.
+CREATE TABLE todo_tombstones_new (
+    id         VARCHAR(24) NOT NULL,
+    user_id    VARCHAR(24) NOT NULL,
+    seq        BIGINT NOT NULL,
+    deleted_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (id, user_id)
+);
+
+INSERT INTO todo_tombstones_new (id, user_id, seq, deleted_at)
+SELECT id, user_id, seq, deleted_at FROM todo_tombstones;
+
+DROP TABLE todo_tombstones;
+
+ALTER TABLE todo_tombstones_new RENAME TO todo_tombstones;
+
+CREATE INDEX todo_tombstones_user_seq ON todo_tombstones (user_id, seq);
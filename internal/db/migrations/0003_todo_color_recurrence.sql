@@ -0,0 +1,5 @@
+-- Optional display color and recurrence of a todo. The timezone is the
+-- zone the recurrence rule is evaluated in.
+ALTER TABLE todos ADD COLUMN color VARCHAR(7) NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+ALTER TABLE todos ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
@@ -6,22 +6,24 @@ import (
 
 	"github.com/developwithayush/go-todo-app/internal/config"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 var (
 	Client *mongo.Client
 	DB     *mongo.Database
-	Users  *mongo.Collection
-	Todos  *mongo.Collection
 )
 
 func InitMongo(cfg *config.Config, logr logger.Logger) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.MongoURI).
+		SetMonitor(chainMonitors(metrics.MongoMonitor(), otelmongo.NewMonitor())))
 	if err != nil {
 		return err
 	}
@@ -34,8 +36,6 @@ func InitMongo(cfg *config.Config, logr logger.Logger) error {
 
 	Client = client
 	DB = db
-	Users = DB.Collection("users")
-	Todos = DB.Collection("todos")
 
 	logr.Info("Connected to MongoDB", logger.Field("uri", cfg.MongoURI),
 		logger.Field("database", cfg.MongoDB),
@@ -43,3 +43,24 @@ func InitMongo(cfg *config.Config, logr logger.Logger) error {
 
 	return nil
 }
+
+// chainMonitors calls each monitor in turn, since the client takes only one.
+func chainMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			for _, m := range monitors {
+				m.Started(ctx, e)
+			}
+		},
+		Succeeded: func(ctx context.Context, e *event.CommandSucceededEvent) {
+			for _, m := range monitors {
+				m.Succeeded(ctx, e)
+			}
+		},
+		Failed: func(ctx context.Context, e *event.CommandFailedEvent) {
+			for _, m := range monitors {
+				m.Failed(ctx, e)
+			}
+		},
+	}
+}
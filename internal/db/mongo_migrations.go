@@ -0,0 +1,301 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	migrationsCollection = "migrations"
+	migrationLockID      = "lock"
+	migrationLockTTL     = 5 * time.Minute
+)
+
+// MongoMigration is one versioned schema change. Down undoes Up and may be
+// nil for changes that cannot be reverted.
+type MongoMigration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, database *mongo.Database) error
+	Down        func(ctx context.Context, database *mongo.Database) error
+}
+
+// MigrationStatus reports whether a migration has been applied.
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+// mongoMigrations lists every migration in version order. Append new ones;
+// never edit or reorder applied ones.
+var mongoMigrations = []MongoMigration{
+	{
+		Version:     1,
+		Description: "unique index on users.email",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			return createIndexes(ctx, database.Collection("users"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetName("email_unique").SetUnique(true),
+			})
+		},
+		Down: func(ctx context.Context, database *mongo.Database) error {
+			return dropIndexes(ctx, database.Collection("users"), "email_unique")
+		},
+	},
+	{
+		Version:     2,
+		Description: "todo list and delta sync indexes",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			err := createIndexes(ctx, database.Collection("todos"),
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "position", Value: 1}},
+					Options: options.Index().SetName("userId_position"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "seq", Value: 1}},
+					Options: options.Index().SetName("userId_seq"),
+				},
+			)
+			if err != nil {
+				return err
+			}
+			return createIndexes(ctx, database.Collection("todo_tombstones"), mongo.IndexModel{
+				Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "seq", Value: 1}},
+				Options: options.Index().SetName("userId_seq"),
+			})
+		},
+		Down: func(ctx context.Context, database *mongo.Database) error {
+			if err := dropIndexes(ctx, database.Collection("todos"), "userId_position", "userId_seq"); err != nil {
+				return err
+			}
+			return dropIndexes(ctx, database.Collection("todo_tombstones"), "userId_seq")
+		},
+	},
+	{
+		Version:     3,
+		Description: "backfill version on todos created before optimistic concurrency",
+		Up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("todos").UpdateMany(ctx,
+				bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": int64(1)}})
+			return err
+		},
+	},
+	{
+		Version:     4,
+		Description: "backfill seq and change counters on todos created before delta sync",
+		Up:          backfillTodoSeq,
+	},
+}
+
+// backfillTodoSeq gives every todo without a seq one from its user's
+// change counter, in position order, so a full sync returns it. The
+// counter is raised first: a run that stops halfway leaves a gap, not a
+// seq that a later write reuses, and running it again picks up the rest.
+func backfillTodoSeq(ctx context.Context, database *mongo.Database) error {
+	todos := database.Collection("todos")
+	counters := database.Collection("counters")
+	missing := bson.M{"seq": bson.M{"$in": bson.A{nil, 0}}}
+
+	users, err := todos.Distinct(ctx, "userId", missing)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		userID, ok := user.(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+		filter := bson.M{"userId": userID, "seq": missing["seq"]}
+
+		cur, err := todos.Find(ctx, filter,
+			options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "_id", Value: 1}}).SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return err
+		}
+		var ids []struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cur.All(ctx, &ids); err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		var counter struct {
+			Seq int64 `bson:"seq"`
+		}
+		err = counters.FindOneAndUpdate(ctx,
+			bson.M{"_id": "todos:" + userID.Hex()}, // the todo repository's counter ID
+			bson.M{"$inc": bson.M{"seq": int64(len(ids))}},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&counter)
+		if err != nil {
+			return err
+		}
+
+		seq := counter.Seq - int64(len(ids))
+		for _, doc := range ids {
+			seq++
+			_, err := todos.UpdateOne(ctx,
+				bson.M{"_id": doc.ID, "seq": missing["seq"]},
+				bson.M{"$set": bson.M{"seq": seq}})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MigrateMongoUp applies every pending migration.
+func MigrateMongoUp(ctx context.Context, database *mongo.Database, logr logger.Logger) error {
+	return withMigrationLock(ctx, database, func() error {
+		applied, err := appliedVersions(ctx, database)
+		if err != nil {
+			return err
+		}
+		for _, m := range mongoMigrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := m.Up(ctx, database); err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+			}
+			_, err := database.Collection(migrationsCollection).InsertOne(ctx, bson.M{
+				"_id":         m.Version,
+				"description": m.Description,
+				"appliedAt":   time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+			logr.Info("applied migration", logger.Field("version", m.Version), logger.Field("description", m.Description))
+		}
+		return nil
+	})
+}
+
+// MigrateMongoDown reverts the last steps applied migrations, newest first.
+func MigrateMongoDown(ctx context.Context, database *mongo.Database, logr logger.Logger, steps int) error {
+	return withMigrationLock(ctx, database, func() error {
+		applied, err := appliedVersions(ctx, database)
+		if err != nil {
+			return err
+		}
+		for i := len(mongoMigrations) - 1; i >= 0 && steps > 0; i-- {
+			m := mongoMigrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == nil {
+				return fmt.Errorf("migration %d (%s) cannot be reverted", m.Version, m.Description)
+			}
+			if err := m.Down(ctx, database); err != nil {
+				return fmt.Errorf("revert migration %d (%s): %w", m.Version, m.Description, err)
+			}
+			if _, err := database.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"_id": m.Version}); err != nil {
+				return err
+			}
+			logr.Info("reverted migration", logger.Field("version", m.Version), logger.Field("description", m.Description))
+			steps--
+		}
+		return nil
+	})
+}
+
+// MongoMigrationStatus lists all migrations and when they were applied.
+func MongoMigrationStatus(ctx context.Context, database *mongo.Database) ([]MigrationStatus, error) {
+	applied, err := appliedVersions(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(mongoMigrations))
+	for _, m := range mongoMigrations {
+		status := MigrationStatus{Version: m.Version, Description: m.Description}
+		if at, ok := applied[m.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func appliedVersions(ctx context.Context, database *mongo.Database) (map[int]time.Time, error) {
+	cur, err := database.Collection(migrationsCollection).Find(ctx, bson.M{"_id": bson.M{"$type": "number"}})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var records []struct {
+		Version   int       `bson:"_id"`
+		AppliedAt time.Time `bson:"appliedAt"`
+	}
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]time.Time, len(records))
+	for _, r := range records {
+		applied[r.Version] = r.AppliedAt
+	}
+	return applied, nil
+}
+
+// withMigrationLock runs fn while holding a lock document in the
+// migrations collection, so replicas starting together migrate one at a
+// time. A lock left behind by a crashed process expires after
+// migrationLockTTL.
+func withMigrationLock(ctx context.Context, database *mongo.Database, fn func() error) error {
+	coll := database.Collection(migrationsCollection)
+	host, _ := os.Hostname()
+	owner := host + ":" + strconv.Itoa(os.Getpid()) + ":" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	for {
+		now := time.Now()
+		_, err := coll.UpdateOne(ctx,
+			bson.M{"_id": migrationLockID, "expiresAt": bson.M{"$lt": now}},
+			bson.M{"$set": bson.M{"owner": owner, "expiresAt": now.Add(migrationLockTTL)}},
+			options.Update().SetUpsert(true))
+		if err == nil {
+			break
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		// another process holds the lock
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for the migration lock")
+		case <-time.After(time.Second):
+		}
+	}
+	defer coll.DeleteOne(context.Background(), bson.M{"_id": migrationLockID, "owner": owner})
+
+	return fn()
+}
+
+func createIndexes(ctx context.Context, coll *mongo.Collection, models ...mongo.IndexModel) error {
+	_, err := coll.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func dropIndexes(ctx context.Context, coll *mongo.Collection, names ...string) error {
+	for _, name := range names {
+		if _, err := coll.Indexes().DropOne(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+	_ "modernc.org/sqlite"             // registers the "sqlite" driver
+)
+
+const (
+	DriverMongo    = "mongo"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	// DriverMemory keeps everything in process memory and loses it on
+	// restart. It is for tests and trying the API without a database.
+	DriverMemory = "memory"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// SQL is a database/sql connection together with the dialect it speaks.
+type SQL struct {
+	*sql.DB
+	Driver string
+}
+
+// Querier is what both *sql.DB and *sql.Tx offer.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// OpenSQL connects to the SQL database selected by cfg.StorageDriver.
+func OpenSQL(cfg *config.Config, logr logger.Logger) (*SQL, error) {
+	var driverName string
+	switch cfg.StorageDriver {
+	case DriverPostgres:
+		driverName = "pgx"
+	case DriverSQLite:
+		driverName = "sqlite"
+	default:
+		return nil, fmt.Errorf("unsupported SQL driver %q", cfg.StorageDriver)
+	}
+
+	conn, err := sql.Open(driverName, cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.StorageDriver == DriverSQLite {
+		// SQLite allows one writer at a time; a single connection avoids
+		// "database is locked" errors and keeps :memory: databases shared.
+		conn.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	logr.Info("Connected to SQL database", logger.Field("driver", cfg.StorageDriver))
+	return &SQL{DB: conn, Driver: cfg.StorageDriver}, nil
+}
+
+// Conn returns the transaction running in ctx, or the database.
+func (d *SQL) Conn(ctx context.Context) Querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return d.DB
+}
+
+// WithTx runs fn in a transaction. Queries made through Conn with the ctx
+// passed to fn join it. A transaction already running in ctx is reused.
+func (d *SQL) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Rebind rewrites ? placeholders into the form the driver expects.
+func (d *SQL) Rebind(query string) string {
+	if d.Driver != DriverPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// migrationLockKey is the Postgres advisory lock held while migrating, so
+// replicas starting together apply each migration once.
+const migrationLockKey = 727361
+
+// Migrate applies the embedded migrations that have not run yet, each in
+// its own transaction, in file name order. Every file is sent as one
+// script, so statements may contain semicolons of their own.
+//
+// Each transaction first takes a lock that concurrent runs wait on, and
+// only then checks whether the migration still has to be applied.
+func (d *SQL) Migrate(ctx context.Context) error {
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		script, err := migrations.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		err = d.withMigrationLock(ctx, func(q Querier) error {
+			_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    VARCHAR(255) PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL
+)`)
+			if err != nil {
+				return err
+			}
+
+			var applied int
+			err = q.QueryRowContext(ctx, d.Rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), version).Scan(&applied)
+			if err != nil || applied > 0 {
+				return err
+			}
+
+			if _, err := q.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err = q.ExecContext(ctx,
+				d.Rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`),
+				version, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// withMigrationLock runs fn in a transaction that no other migration runs
+// alongside. Postgres takes an advisory lock released on commit. SQLite
+// begins the transaction IMMEDIATE, taking the write lock up front instead
+// of at the first write, when two runs would both have read the version
+// as missing.
+func (d *SQL) withMigrationLock(ctx context.Context, fn func(q Querier) error) error {
+	if d.Driver == DriverPostgres {
+		return d.WithTx(ctx, func(ctx context.Context) error {
+			q := d.Conn(ctx)
+			if _, err := q.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+				return err
+			}
+			return fn(q)
+		})
+	}
+
+	// database/sql cannot begin an IMMEDIATE transaction, so it is run by
+	// hand on a connection of its own
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// wait for another process holding the lock rather than fail at once
+	if _, err := conn.ExecContext(ctx, `PRAGMA busy_timeout = 30000`); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return err
+	}
+	if err := fn(conn); err != nil {
+		conn.ExecContext(context.WithoutCancel(ctx), `ROLLBACK`)
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `COMMIT`)
+	return err
+}
+
+// PendingMigrations returns the embedded migrations that have not been
+// applied yet.
+func (d *SQL) PendingMigrations(ctx context.Context) ([]string, error) {
+	rows, err := d.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var pending []string
+	for _, name := range names {
+		version := strings.TrimSuffix(strings.TrimPrefix(name, "migrations/"), ".sql")
+		if !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+	return pending, nil
+}
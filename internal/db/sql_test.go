@@ -0,0 +1,110 @@
+package db_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+)
+
+func TestMigrateConcurrently(t *testing.T) {
+	cfg := &config.Config{
+		StorageDriver: db.DriverSQLite,
+		DatabaseURL:   "file:" + filepath.Join(t.TempDir(), "test.db"),
+	}
+
+	// separate handles stand in for replicas starting at the same time
+	const replicas = 8
+	errs := make([]error, replicas)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := range replicas {
+		database, err := db.OpenSQL(cfg, logger.Nop())
+		if err != nil {
+			t.Fatalf("OpenSQL: %v", err)
+		}
+		t.Cleanup(func() { database.Close() })
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = database.Migrate(context.Background())
+		}()
+	}
+	close(start)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Migrate %d: %v", i, err)
+		}
+	}
+
+	database, err := db.OpenSQL(cfg, logger.Nop())
+	if err != nil {
+		t.Fatalf("OpenSQL: %v", err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate again: %v", err)
+	}
+	pending, err := database.PendingMigrations(ctx)
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("PendingMigrations = %v, %v; want none", pending, err)
+	}
+	// every statement of the file ran, not just the first
+	for _, table := range []string{"users", "todos", "todo_tombstones", "counters"} {
+		if _, err := database.Exec(`SELECT COUNT(*) FROM ` + table); err != nil {
+			t.Errorf("table %s: %v", table, err)
+		}
+	}
+}
+
+func TestMigrateWaitsForLock(t *testing.T) {
+	cfg := &config.Config{
+		StorageDriver: db.DriverSQLite,
+		DatabaseURL:   "file:" + filepath.Join(t.TempDir(), "test.db"),
+	}
+	holder, err := db.OpenSQL(cfg, logger.Nop())
+	if err != nil {
+		t.Fatalf("OpenSQL: %v", err)
+	}
+	defer holder.Close()
+	migrator, err := db.OpenSQL(cfg, logger.Nop())
+	if err != nil {
+		t.Fatalf("OpenSQL: %v", err)
+	}
+	defer migrator.Close()
+
+	ctx := context.Background()
+	conn, err := holder.DB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		t.Fatalf("BEGIN IMMEDIATE: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- migrator.Migrate(ctx) }()
+	select {
+	case err := <-done:
+		t.Fatalf("Migrate returned %v while another writer held the lock", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if _, err := conn.ExecContext(ctx, `COMMIT`); err != nil {
+		t.Fatalf("COMMIT: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"time"
 
+	"github.com/developwithayush/go-todo-app/internal/apperr"
 	"github.com/developwithayush/go-todo-app/internal/config"
 	"github.com/developwithayush/go-todo-app/internal/dto"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/util"
 	"github.com/gofiber/fiber/v3"
 )
 
@@ -38,21 +40,15 @@ func NewHandler(authService *Service, config *config.Config, logr logger.Logger)
 func (h *Handler) SendOTP(c fiber.Ctx) error {
 	var body dto.SendOTPRequest
 
-	if err := c.Bind().Body(&body); err != nil || body.Email == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request body",
-		})
+	if err := c.Bind().Body(&body); err != nil {
+		return apperr.InvalidBody(err)
 	}
 
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
 
 	if err := h.authService.SendOTP(ctx, body.Email); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to send OTP",
-		})
+		return apperr.Wrap(err, "Failed to send OTP")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -75,12 +71,9 @@ func (h *Handler) SendOTP(c fiber.Ctx) error {
 func (h *Handler) VerifyOTP(c fiber.Ctx) error {
 	var body dto.VerifyOTPRequest
 
-	if err := c.Bind().Body(&body); err != nil || body.Email == "" || body.OTP == "" {
-		h.logr.Error("Invalid request body", logger.Field("error", err), logger.Field("email", body.Email), logger.Field("otp", body.OTP))
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request body",
-		})
+	if err := c.Bind().Body(&body); err != nil {
+		logger.FromContext(c.Context(), h.logr).Warn("Invalid request body", logger.Field("email", body.Email))
+		return apperr.InvalidBody(err)
 	}
 
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
@@ -88,13 +81,10 @@ func (h *Handler) VerifyOTP(c fiber.Ctx) error {
 
 	token, err := h.authService.VerifyOTP(ctx, body.Email, body.OTP)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to verify OTP",
-		})
+		return apperr.Wrap(err, "Failed to verify OTP")
 	}
 
-	secure := h.config.Env == "production" || h.config.Env == "prod"
+	secure := h.config.IsProduction()
 
 	cookie := fiber.Cookie{
 		Name:     h.config.CookieName,
@@ -113,3 +103,30 @@ func (h *Handler) VerifyOTP(c fiber.Ctx) error {
 		"token":   token,
 	})
 }
+
+// CSRFToken godoc
+// @Summary Get a CSRF token
+// @Description Returns a CSRF token for the current session and also sets it in a readable cookie. Authenticated POST, PUT, PATCH and DELETE requests must send it in the X-CSRF-Token header. It stops working when the session changes.
+// @Tags Authentication
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} dto.CSRFTokenResponse "CSRF token"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Router /auth/csrf [get]
+func (h *Handler) CSRFToken(c fiber.Ctx) error {
+	token, err := util.NewCSRFToken(h.config.JWTSecret, c.Cookies(h.config.CookieName))
+	if err != nil {
+		return apperr.Internal("Failed to create CSRF token", err)
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     h.config.CSRFCookieName,
+		Value:    token,
+		Expires:  time.Now().Add(1 * time.Hour),
+		Secure:   h.config.IsProduction(),
+		SameSite: "lax",
+	})
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	return c.JSON(dto.CSRFTokenResponse{Token: token})
+}
@@ -3,15 +3,21 @@ package auth
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
+	"github.com/developwithayush/go-todo-app/internal/apperr"
 	"github.com/developwithayush/go-todo-app/internal/config"
 	"github.com/developwithayush/go-todo-app/internal/domain/user"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
 	"github.com/developwithayush/go-todo-app/internal/util"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// ErrInvalidOTP is returned for a wrong, expired or unknown code alike, so
+// the response does not tell which emails have an account.
+var ErrInvalidOTP = apperr.Unauthorized("invalid_otp", "Invalid or expired OTP")
+
 type Service struct {
 	userRepo user.Repository
 	mailer *util.Mailer
@@ -31,6 +37,16 @@ func NewService(cfg *config.Config, userRepo user.Repository, mailer *util.Maile
 
 
 func (s *Service) SendOTP(ctx context.Context, email string) error {
+	err := s.sendOTP(ctx, email)
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	metrics.OTPEvents.WithLabelValues("send", result).Inc()
+	return err
+}
+
+func (s *Service) sendOTP(ctx context.Context, email string) error {
 	otp := util.GenerateOTP()
 	hashedOTP, err := util.HashOTP(otp)
 	if err != nil {
@@ -42,24 +58,31 @@ func (s *Service) SendOTP(ctx context.Context, email string) error {
 	if err != nil {
 		return err
 	}
-	return s.mailer.SendOTP(user.Email, otp) 
+	return s.mailer.SendOTP(ctx, user.Email, otp) 
 }
 
 
 func (s *Service) VerifyOTP(ctx context.Context, email, otp string) (string, error) {
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		return "", errors.New("user not found")
+		result := lookupResult(err)
+		metrics.OTPEvents.WithLabelValues("verify", result).Inc()
+		if result == "not_found" {
+			return "", ErrInvalidOTP
+		}
+		return "", err
 	} 
 
 	if time.Now().After(user.OTPExpiresAt) { 
-		fmt.Println("otp expired")
-		return " ", errors.New("otp expired")
+		logger.FromContext(ctx, logger.Nop()).Debug("otp expired", logger.Field("user_id", user.ID))
+		metrics.OTPEvents.WithLabelValues("verify", "expired").Inc()
+		return "", ErrInvalidOTP
 	}
 	
 	if !util.CheckOTP(user.OTPHash, otp) {
-		fmt.Println("invalid otp")
-		return "", errors.New("invalid otp")
+		logger.FromContext(ctx, logger.Nop()).Debug("invalid otp", logger.Field("user_id", user.ID))
+		metrics.OTPEvents.WithLabelValues("verify", "invalid").Inc()
+		return "", ErrInvalidOTP
 	}
 
 	_ = s.userRepo.ClearOTP(ctx, user.ID)
@@ -73,7 +96,17 @@ func (s *Service) VerifyOTP(ctx context.Context, email, otp string) (string, err
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(s.config.JWTSecret))
 	if err != nil {
+		metrics.OTPEvents.WithLabelValues("verify", "error").Inc()
 		return "", err
 	}
+	metrics.OTPEvents.WithLabelValues("verify", "ok").Inc()
 	return tokenString, nil
+}
+
+// lookupResult labels a failed user lookup during verification.
+func lookupResult(err error) string {
+	if errors.Is(err, user.ErrNotFound) {
+		return "not_found"
+	}
+	return "error"
 }
\ No newline at end of file
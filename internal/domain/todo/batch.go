@@ -0,0 +1,362 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/dto"
+	"github.com/developwithayush/go-todo-app/internal/realtime"
+	"github.com/developwithayush/go-todo-app/internal/util"
+	"github.com/developwithayush/go-todo-app/internal/validate"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	BatchOK         = "ok"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+)
+
+// ErrBatchRolledBack is returned when an atomic batch is aborted. The
+// problem body carries the per-operation results.
+var ErrBatchRolledBack = apperr.New(apperr.KindUnprocessable, "batch_rolled_back", "Batch rolled back")
+
+var errInvalidAtomic = apperr.Validation("invalid_query", "Invalid query parameter",
+	apperr.FieldError{Field: "atomic", Code: "type", Message: "atomic must be true or false"})
+
+var (
+	errBatchAborted    = errors.New("batch aborted")
+	errUnknownOp       = errors.New("unknown op")
+	errMissingPosition = errors.New("position is required")
+)
+
+// BatchResult is the outcome of one batch operation.
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Todo   *Todo  `json:"todo,omitempty"`
+}
+
+// batchEvent is published once the operation that produced it is final.
+type batchEvent struct {
+	eventType string
+	data      interface{}
+}
+
+// BatchTodos godoc
+// @Summary Run a batch of todo operations
+// @Description Applies create, update, delete, move and complete operations in order and returns a result for each one. By default operations succeed or fail independently. With atomic=true the batch runs in a transaction: the first failure rolls everything back and the response is a 422 problem whose results member lists each operation. Atomic batches need a database with transactions; a standalone MongoDB answers them with 501.
+// @Tags Todos
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param atomic query bool false "Apply all operations or none"
+// @Param request body dto.BatchRequest true "Operations"
+// @Success 200 {object} dto.BatchResponse "Per-operation results"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request body or atomic flag"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 422 {object} dto.BatchProblemResponse "Atomic batch rolled back"
+// @Failure 500 {object} dto.ErrorResponse "Failed to run batch"
+// @Failure 501 {object} dto.ErrorResponse "Atomic batches need a database with transactions"
+// @Router /todos/batch [post]
+func (h *Handler) BatchTodos(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	var body dto.BatchRequest
+	if err := c.Bind().Body(&body); err != nil {
+		return apperr.InvalidBody(err)
+	}
+	atomic, err := parseAtomic(c.Query("atomic"))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	var results []BatchResult
+	var events []batchEvent
+	if atomic {
+		err = h.repo.WithTransaction(ctx, func(ctx context.Context) error {
+			var runErr error
+			results, events, runErr = h.runBatch(ctx, userID, body.Operations, true)
+			return runErr
+		})
+	} else {
+		results, events, err = h.runBatch(ctx, userID, body.Operations, false)
+	}
+
+	if errors.Is(err, errBatchAborted) {
+		for i := range results {
+			if results[i].Status == BatchOK {
+				results[i].Status = BatchRolledBack
+				results[i].Todo = nil
+			}
+		}
+		return ErrBatchRolledBack.WithExtension("results", results)
+	}
+	if err != nil {
+		return apperr.Wrap(err, "Failed to run batch")
+	}
+
+	for _, ev := range events {
+		h.publish(ctx, userID, ev.eventType, ev.data)
+	}
+	return util.OK(c, results)
+}
+
+// parseAtomic accepts the values strconv.ParseBool does, such as true, 1
+// and false. An absent flag means false.
+func parseAtomic(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	atomic, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errInvalidAtomic
+	}
+	return atomic, nil
+}
+
+// CompleteAll godoc
+// @Summary Complete all open todos
+// @Description Moves every todo of the authenticated user that is not in a done status to the done status
+// @Tags Todos
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} dto.CountResponse "Number of todos completed"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 500 {object} dto.ErrorResponse "Failed to complete todos"
+// @Router /todos/complete-all [post]
+func (h *Handler) CompleteAll(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	todos, err := h.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to complete todos")
+	}
+
+	count := 0
+	done := true
+	for i := range todos {
+		if h.workflow.IsDone(h.workflow.StatusOf(&todos[i])) {
+			continue
+		}
+		updated, changed, err := h.applyChanges(ctx, &todos[i], changes{Completed: &done})
+		if errors.Is(err, ErrVersionMismatch) || errors.Is(err, ErrNotFound) {
+			// changed or removed concurrently; leave it to the other writer
+			continue
+		}
+		if err != nil {
+			return apperr.Wrap(err, "Failed to complete todos")
+		}
+		if changed {
+			h.publish(ctx, userID, realtime.EventTodoUpdated, updated)
+			count++
+		}
+	}
+
+	return util.OK(c, fiber.Map{"count": count})
+}
+
+// DeleteCompleted godoc
+// @Summary Delete all completed todos
+// @Description Deletes every todo of the authenticated user that is in a done status
+// @Tags Todos
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} dto.CountResponse "Number of todos deleted"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 500 {object} dto.ErrorResponse "Failed to delete todos"
+// @Router /todos/completed [delete]
+func (h *Handler) DeleteCompleted(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	todos, err := h.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to delete todos")
+	}
+
+	count := 0
+	for i := range todos {
+		if !h.workflow.IsDone(h.workflow.StatusOf(&todos[i])) {
+			continue
+		}
+		err := h.repo.Delete(ctx, userID, todos[i].ID, todos[i].Version)
+		if errors.Is(err, ErrVersionMismatch) || errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return apperr.Wrap(err, "Failed to delete todos")
+		}
+		h.publish(ctx, userID, realtime.EventTodoDeleted, fiber.Map{"id": todos[i].ID})
+		count++
+	}
+
+	return util.OK(c, fiber.Map{"count": count})
+}
+
+// runBatch applies the operations in order. In atomic mode it stops at the
+// first failure and returns errBatchAborted so the transaction rolls back.
+func (h *Handler) runBatch(ctx context.Context, userID primitive.ObjectID, ops []dto.BatchOperation, atomic bool) ([]BatchResult, []batchEvent, error) {
+	results := make([]BatchResult, 0, len(ops))
+	events := make([]batchEvent, 0, len(ops))
+
+	for i, op := range ops {
+		res, ev, err := h.applyOperation(ctx, userID, op)
+		res.Index = i
+		res.Op = op.Op
+		if err != nil {
+			res.Status = BatchFailed
+			res.Error = batchError(err)
+			results = append(results, res)
+			if atomic {
+				return results, nil, errBatchAborted
+			}
+			continue
+		}
+
+		res.Status = BatchOK
+		results = append(results, res)
+		if ev != nil {
+			events = append(events, *ev)
+		}
+	}
+	return results, events, nil
+}
+
+func (h *Handler) applyOperation(ctx context.Context, userID primitive.ObjectID, op dto.BatchOperation) (BatchResult, *batchEvent, error) {
+	res := BatchResult{ID: op.ID}
+	if err := validate.Struct(op); err != nil {
+		return res, nil, err
+	}
+
+	if op.Op == "create" {
+		title, description, status := "", "", ""
+		if op.Title != nil {
+			title = *op.Title
+		}
+		if op.Description != nil {
+			description = *op.Description
+		}
+		if op.Status != nil {
+			status = *op.Status
+		}
+		todo, err := h.newTodo(ctx, userID, primitive.NewObjectID(), title, description, status)
+		if err != nil {
+			return res, nil, err
+		}
+		created, err := h.repo.Create(ctx, todo)
+		if err != nil {
+			return res, nil, err
+		}
+		res.ID = created.ID.Hex()
+		res.Todo = created
+		return res, &batchEvent{realtime.EventTodoCreated, created}, nil
+	}
+
+	todoID, err := primitive.ObjectIDFromHex(op.ID)
+	if err != nil {
+		return res, nil, ErrNotFound
+	}
+
+	if op.Op == "delete" {
+		if err := h.repo.Delete(ctx, userID, todoID, op.Version); err != nil {
+			return res, nil, err
+		}
+		return res, &batchEvent{realtime.EventTodoDeleted, fiber.Map{"id": todoID}}, nil
+	}
+
+	current, err := h.repo.FindByID(ctx, userID, todoID)
+	if err != nil {
+		return res, nil, err
+	}
+	if op.Version != 0 && op.Version != current.Version {
+		return res, nil, ErrVersionMismatch
+	}
+
+	var ch changes
+	eventType := realtime.EventTodoUpdated
+	switch op.Op {
+	case "update":
+		ch = changes{
+			Title:       op.Title,
+			Description: op.Description,
+			Status:      op.Status,
+			Completed:   op.Completed,
+			Position:    op.Position,
+		}
+	case "move":
+		if op.Position == nil {
+			return res, nil, errMissingPosition
+		}
+		ch = changes{Position: op.Position}
+		eventType = realtime.EventTodoReordered
+	case "complete":
+		done := true
+		ch = changes{Completed: &done}
+	default:
+		return res, nil, errUnknownOp
+	}
+
+	updated, changed, err := h.applyChanges(ctx, current, ch)
+	if err != nil {
+		return res, nil, err
+	}
+	res.Todo = updated
+	if !changed {
+		return res, nil, nil
+	}
+	if eventType == realtime.EventTodoReordered {
+		return res, &batchEvent{eventType, fiber.Map{"id": updated.ID, "position": updated.Position}}, nil
+	}
+	return res, &batchEvent{eventType, updated}, nil
+}
+
+// batchError turns an operation error into a client-facing message
+// without leaking storage errors.
+func batchError(err error) string {
+	if msg, ok := fieldMessage(err); ok {
+		return msg
+	}
+	for _, known := range []error{ErrNotFound, ErrVersionMismatch, ErrInvalidStatus, ErrTitleRequired, errUnknownOp, errMissingPosition} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return "operation failed"
+}
+
+// fieldMessage returns the message for the first invalid field of a
+// validation error, for per-item results that carry a single string.
+func fieldMessage(err error) (string, bool) {
+	var e *apperr.Error
+	if !errors.Is(err, validate.ErrFailed) || !errors.As(err, &e) || len(e.Fields) == 0 {
+		return "", false
+	}
+	return e.Fields[0].Message, true
+}
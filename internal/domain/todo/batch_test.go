@@ -0,0 +1,122 @@
+package todo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/developwithayush/go-todo-app/internal/domain/todo"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAtomicBatchRollbackIsProblem(t *testing.T) {
+	repo := todo.NewMemoryRepository()
+	user := primitive.NewObjectID()
+	app := handlerApp(repo, user, fiber.MethodPost, "/todos/batch", (*todo.Handler).BatchTodos)
+
+	body := `{"operations":[{"op":"create","title":"Buy milk"},{"op":"complete","id":"` + primitive.NewObjectID().Hex() + `"}]}`
+	req := httptest.NewRequest(fiber.MethodPost, "/todos/batch?atomic=true", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("POST /todos/batch: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusUnprocessableEntity || resp.Header.Get(fiber.HeaderContentType) != "application/problem+json" {
+		t.Fatalf("got %d %s, want a 422 problem", resp.StatusCode, resp.Header.Get(fiber.HeaderContentType))
+	}
+	var problem struct {
+		Status  int                `json:"status"`
+		Code    string             `json:"code"`
+		Success *bool              `json:"success"`
+		Results []todo.BatchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if problem.Status != fiber.StatusUnprocessableEntity || problem.Code != "batch_rolled_back" || problem.Success != nil {
+		t.Fatalf("problem = %+v, want status 422 and code batch_rolled_back", problem)
+	}
+	if len(problem.Results) != 2 {
+		t.Fatalf("results = %+v, want one per operation", problem.Results)
+	}
+	if r := problem.Results[0]; r.Status != todo.BatchRolledBack || r.Todo != nil {
+		t.Errorf("results[0] = %+v, want rolled_back without a todo", r)
+	}
+	if r := problem.Results[1]; r.Status != todo.BatchFailed || r.Error != todo.ErrNotFound.Error() {
+		t.Errorf("results[1] = %+v, want failed with %q", r, todo.ErrNotFound.Error())
+	}
+
+	todos, err := repo.ListByUser(context.Background(), user)
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if len(todos) != 0 {
+		t.Fatalf("rolled back batch left %d todos", len(todos))
+	}
+}
+
+// standaloneRepo is a store that cannot run transactions, like a
+// standalone MongoDB.
+type standaloneRepo struct {
+	todo.Repository
+}
+
+func (standaloneRepo) WithTransaction(context.Context, func(ctx context.Context) error) error {
+	return todo.ErrTransactionsUnsupported
+}
+
+func postBatch(t *testing.T, app *fiber.App, query, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/todos/batch"+query, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("POST /todos/batch: %v", err)
+	}
+	defer resp.Body.Close()
+	var problem struct {
+		Code string `json:"code"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&problem)
+	return resp.StatusCode, problem.Code
+}
+
+func TestAtomicBatchWithoutTransactions(t *testing.T) {
+	repo := standaloneRepo{todo.NewMemoryRepository()}
+	user := primitive.NewObjectID()
+	app := handlerApp(repo, user, fiber.MethodPost, "/todos/batch", (*todo.Handler).BatchTodos)
+	body := `{"operations":[{"op":"create","title":"Buy milk"}]}`
+
+	status, code := postBatch(t, app, "?atomic=true", body)
+	if status != fiber.StatusNotImplemented || code != "transactions_unsupported" {
+		t.Fatalf("atomic batch got %d %s, want 501 transactions_unsupported", status, code)
+	}
+	if todos, _ := repo.ListByUser(context.Background(), user); len(todos) != 0 {
+		t.Fatalf("unsupported atomic batch wrote %d todos", len(todos))
+	}
+
+	if status, _ := postBatch(t, app, "", body); status != fiber.StatusOK {
+		t.Fatalf("non-atomic batch got %d, want 200", status)
+	}
+}
+
+func TestBatchAtomicFlag(t *testing.T) {
+	app := handlerApp(todo.NewMemoryRepository(), primitive.NewObjectID(), fiber.MethodPost, "/todos/batch", (*todo.Handler).BatchTodos)
+	body := `{"operations":[{"op":"create","title":"Buy milk"}]}`
+
+	for _, query := range []string{"", "?atomic=true", "?atomic=1", "?atomic=false", "?atomic=0"} {
+		if status, _ := postBatch(t, app, query, body); status != fiber.StatusOK {
+			t.Errorf("%q: status %d, want 200", query, status)
+		}
+	}
+	for _, query := range []string{"?atomic=yes", "?atomic=TRUEE", "?atomic=on"} {
+		if status, code := postBatch(t, app, query, body); status != fiber.StatusBadRequest || code != "invalid_query" {
+			t.Errorf("%q: got %d %s, want 400 invalid_query", query, status, code)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/cache"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/sync/singleflight"
+)
+
+const listCacheName = "todo_list"
+
+const (
+	// listLoadTimeout bounds a shared list read, which does not end with
+	// the request that started it
+	listLoadTimeout = 10 * time.Second
+	// invalidateAttempts is how often a generation bump is tried before
+	// the user's lists bypass the cache
+	invalidateAttempts = 3
+)
+
+// cachedRepo caches ListByUser in a cache store. Each user has a generation
+// counter that every write bumps; lists are cached under the current
+// generation, so a write makes older entries unreachable even if a slow
+// reader stores a list it loaded before the write.
+//
+// A write whose bump fails leaves the old generation in place, and with it
+// a list that no longer matches. Such users are marked stale: this replica
+// reads their lists from the repository until a later bump succeeds.
+// Other replicas may serve the old list until it expires.
+type cachedRepo struct {
+	Repository
+	store cache.Store
+	ttl   time.Duration
+	logr  logger.Logger
+	group singleflight.Group
+
+	staleMu sync.Mutex
+	stale   map[primitive.ObjectID]struct{}
+}
+
+type txUsersKey struct{}
+
+// txUsers collects the users written inside a transaction so their lists
+// can be invalidated again once it commits.
+type txUsers struct {
+	mu  sync.Mutex
+	ids map[primitive.ObjectID]struct{}
+}
+
+// NewCachedRepository wraps next with a read-through cache of todo lists.
+// When the store is unavailable reads go straight to next.
+func NewCachedRepository(next Repository, store cache.Store, ttl time.Duration, logr logger.Logger) Repository {
+	return &cachedRepo{
+		Repository: next,
+		store:      store,
+		ttl:        ttl,
+		logr:       logr,
+		stale:      map[primitive.ObjectID]struct{}{},
+	}
+}
+
+func (r *cachedRepo) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Todo, error) {
+	if _, inTx := ctx.Value(txUsersKey{}).(*txUsers); inTx {
+		// reads inside a transaction must see its own writes
+		return r.Repository.ListByUser(ctx, userID)
+	}
+	if r.isStale(userID) && r.bump(ctx, userID, 1) != nil {
+		metrics.CacheRequests.WithLabelValues(listCacheName, "error").Inc()
+		return r.load(ctx, userID, "")
+	}
+
+	gen, err := r.store.Get(ctx, generationKey(userID))
+	if errors.Is(err, cache.ErrMiss) {
+		gen, err = []byte("0"), nil
+	}
+	if err != nil {
+		metrics.CacheRequests.WithLabelValues(listCacheName, "error").Inc()
+		return r.load(ctx, userID, "")
+	}
+
+	key := listKey(userID, string(gen))
+	data, err := r.store.Get(ctx, key)
+	if err == nil {
+		var todos []Todo
+		if err := json.Unmarshal(data, &todos); err == nil {
+			metrics.CacheRequests.WithLabelValues(listCacheName, "hit").Inc()
+			return todos, nil
+		}
+	} else if !errors.Is(err, cache.ErrMiss) {
+		metrics.CacheRequests.WithLabelValues(listCacheName, "error").Inc()
+		return r.load(ctx, userID, "")
+	}
+
+	metrics.CacheRequests.WithLabelValues(listCacheName, "miss").Inc()
+	return r.load(ctx, userID, key)
+}
+
+// load reads the list from the wrapped repository, sharing one read among
+// concurrent callers, and caches it under key when key is set. The read
+// runs on a context of its own: it serves every caller, so the first one
+// going away must not fail the others.
+func (r *cachedRepo) load(ctx context.Context, userID primitive.ObjectID, key string) ([]Todo, error) {
+	v, err, _ := r.group.Do(userID.Hex()+"|"+key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), listLoadTimeout)
+		defer cancel()
+
+		todos, err := r.Repository.ListByUser(ctx, userID)
+		if err != nil || key == "" {
+			return todos, err
+		}
+		if data, err := json.Marshal(todos); err == nil {
+			if err := r.store.Set(ctx, key, data, r.ttl); err != nil {
+				logger.FromContext(ctx, r.logr).Warn("failed to cache todo list", logger.Field("error", err))
+			}
+		}
+		return todos, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// callers sharing a result must not share the slice
+	shared := v.([]Todo)
+	todos := make([]Todo, len(shared))
+	for i := range shared {
+		todos[i] = copyTodo(shared[i])
+	}
+	return todos, nil
+}
+
+func (r *cachedRepo) Create(ctx context.Context, todo Todo) (*Todo, error) {
+	created, err := r.Repository.Create(ctx, todo)
+	if err == nil {
+		r.invalidate(ctx, todo.UserID)
+	}
+	return created, err
+}
+
+func (r *cachedRepo) Update(ctx context.Context, userID, todoID primitive.ObjectID, version int64, update bson.M) (*Todo, error) {
+	updated, err := r.Repository.Update(ctx, userID, todoID, version, update)
+	if err == nil {
+		r.invalidate(ctx, userID)
+	}
+	return updated, err
+}
+
+func (r *cachedRepo) Delete(ctx context.Context, userID, todoID primitive.ObjectID, version int64) error {
+	err := r.Repository.Delete(ctx, userID, todoID, version)
+	if err == nil {
+		r.invalidate(ctx, userID)
+	}
+	return err
+}
+
+// WithTransaction invalidates every list written in the transaction again
+// after it ends: reads that ran while it was open saw the old data and may
+// have cached it under the new generation.
+func (r *cachedRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	users := &txUsers{ids: map[primitive.ObjectID]struct{}{}}
+	err := r.Repository.WithTransaction(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, txUsersKey{}, users))
+	})
+	for userID := range users.ids {
+		r.invalidate(context.WithoutCancel(ctx), userID)
+	}
+	return err
+}
+
+func (r *cachedRepo) invalidate(ctx context.Context, userID primitive.ObjectID) {
+	if users, ok := ctx.Value(txUsersKey{}).(*txUsers); ok {
+		users.mu.Lock()
+		users.ids[userID] = struct{}{}
+		users.mu.Unlock()
+	}
+	if err := r.bump(ctx, userID, invalidateAttempts); err != nil {
+		logger.FromContext(ctx, r.logr).Warn("failed to invalidate todo list cache", logger.Field("error", err))
+		r.staleMu.Lock()
+		r.stale[userID] = struct{}{}
+		r.staleMu.Unlock()
+	}
+}
+
+// bump moves the user's lists to a new generation, trying up to attempts
+// times, and clears the stale mark once it succeeds. The write it follows
+// has happened, so a cancelled request does not stop it.
+func (r *cachedRepo) bump(ctx context.Context, userID primitive.ObjectID, attempts int) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		_, err := r.store.Incr(ctx, generationKey(userID))
+		if err == nil {
+			r.staleMu.Lock()
+			delete(r.stale, userID)
+			r.staleMu.Unlock()
+			return nil
+		}
+		// an unavailable store fails fast and will not recover in time
+		if errors.Is(err, cache.ErrUnavailable) || attempt == attempts {
+			return err
+		}
+		select {
+		case <-time.After(time.Duration(attempt) * 50 * time.Millisecond):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+func (r *cachedRepo) isStale(userID primitive.ObjectID) bool {
+	r.staleMu.Lock()
+	defer r.staleMu.Unlock()
+	_, ok := r.stale[userID]
+	return ok
+}
+
+func generationKey(userID primitive.ObjectID) string {
+	return "todos:gen:" + userID.Hex()
+}
+
+func listKey(userID primitive.ObjectID, gen string) string {
+	return "todos:list:" + userID.Hex() + ":" + gen
+}
@@ -0,0 +1,160 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/cache"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errStoreDown = errors.New("store down")
+
+// flakyStore fails the next failIncr calls of Incr.
+type flakyStore struct {
+	cache.Store
+	mu       sync.Mutex
+	failIncr int
+}
+
+func (s *flakyStore) Incr(ctx context.Context, key string) (int64, error) {
+	s.mu.Lock()
+	if s.failIncr > 0 {
+		s.failIncr--
+		s.mu.Unlock()
+		return 0, errStoreDown
+	}
+	s.mu.Unlock()
+	return s.Store.Incr(ctx, key)
+}
+
+func (s *flakyStore) fail(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failIncr = n
+}
+
+func newCachedTestRepo(store cache.Store) Repository {
+	return NewCachedRepository(NewMemoryRepository(), store, time.Minute, logger.Nop())
+}
+
+func listTitles(t *testing.T, repo Repository, userID primitive.ObjectID) string {
+	t.Helper()
+	todos, err := repo.ListByUser(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	return titles(todos)
+}
+
+func TestCachedRepoRetriesInvalidation(t *testing.T) {
+	store := &flakyStore{Store: cache.NewMemoryStore(100)}
+	repo := newCachedTestRepo(store)
+	ctx := context.Background()
+	user := primitive.NewObjectID()
+
+	created := mustCreate(t, repo, sampleTodo(user, "a", 0))
+	listTitles(t, repo, user)
+
+	store.fail(invalidateAttempts - 1)
+	if _, err := repo.Update(ctx, user, created.ID, 0, bson.M{"title": "b"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := listTitles(t, repo, user); got != "b" {
+		t.Fatalf("list after a retried invalidation = %s, want b", got)
+	}
+}
+
+func TestCachedRepoSkipsCacheUntilInvalidated(t *testing.T) {
+	store := &flakyStore{Store: cache.NewMemoryStore(100)}
+	repo := newCachedTestRepo(store)
+	ctx := context.Background()
+	user := primitive.NewObjectID()
+
+	created := mustCreate(t, repo, sampleTodo(user, "a", 0))
+	if got := listTitles(t, repo, user); got != "a" {
+		t.Fatalf("list = %s, want a", got)
+	}
+
+	// the write lands but the old list stays cached under the generation
+	store.fail(invalidateAttempts + 1)
+	if _, err := repo.Update(ctx, user, created.ID, 0, bson.M{"title": "b"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := listTitles(t, repo, user); got != "b" {
+		t.Fatalf("list after a failed invalidation = %s, want b from the repository", got)
+	}
+
+	// once the store is back the next read applies the invalidation and
+	// caches again
+	if got := listTitles(t, repo, user); got != "b" {
+		t.Fatalf("list after the store recovered = %s, want b", got)
+	}
+	if repo.(*cachedRepo).isStale(user) {
+		t.Fatal("user still marked stale after a successful bump")
+	}
+	gen, err := store.Get(ctx, generationKey(user))
+	if err != nil {
+		t.Fatalf("generation: %v", err)
+	}
+	if _, err := store.Get(ctx, listKey(user, string(gen))); err != nil {
+		t.Fatalf("list not cached under the new generation: %v", err)
+	}
+}
+
+// blockingRepo holds ListByUser until released and reports the state of
+// the context it was given.
+type blockingRepo struct {
+	Repository
+	started chan struct{}
+	release chan struct{}
+	ctxErr  chan error
+}
+
+func (r *blockingRepo) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Todo, error) {
+	close(r.started)
+	<-r.release
+	r.ctxErr <- ctx.Err()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.Repository.ListByUser(ctx, userID)
+}
+
+func TestCachedRepoLoadOutlivesFirstCaller(t *testing.T) {
+	inner := &blockingRepo{
+		Repository: NewMemoryRepository(),
+		started:    make(chan struct{}),
+		release:    make(chan struct{}),
+		ctxErr:     make(chan error, 1),
+	}
+	store := cache.NewMemoryStore(100)
+	repo := NewCachedRepository(inner, store, time.Minute, logger.Nop())
+	user := primitive.NewObjectID()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := repo.ListByUser(ctx, user)
+		done <- err
+	}()
+
+	<-inner.started
+	cancel()
+	close(inner.release)
+
+	if err := <-inner.ctxErr; err != nil {
+		t.Fatalf("shared load saw the first caller's cancellation: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if _, err := store.Get(context.Background(), listKey(user, "0")); err != nil {
+		t.Fatalf("list not cached: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package todo
+
+import (
+	"bufio"
+	"context"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/dto"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ExportTodos godoc
+// @Summary Export todos
+// @Description Downloads all todos of the authenticated user as JSON (an array of records), CSV (with a header row), todo.txt or a Markdown checklist grouped by status. todo.txt keeps the status in a status: tag and leaves out descriptions.
+// @Tags Todos
+// @Produce json
+// @Produce text/csv
+// @Produce text/plain
+// @Produce text/markdown
+// @Security CookieAuth
+// @Param format query string false "File format" Enums(json, csv, todotxt, markdown) default(json)
+// @Success 200 {array} dto.TodoRecord "Todos in the requested format"
+// @Header 200 {string} Content-Disposition "attachment; filename=todos.json"
+// @Failure 400 {object} dto.ErrorResponse "Unknown format"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 500 {object} dto.ErrorResponse "Failed to export todos"
+// @Router /todos/export [get]
+func (h *Handler) ExportTodos(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	var query dto.ExportQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	format := query.Format
+	if format == "" {
+		format = FormatJSON
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	todos, err := h.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to export todos")
+	}
+	h.workflow.Normalize(todos)
+
+	var contentType, ext string
+	for _, f := range formatTypes {
+		if f.format == format {
+			contentType, ext = f.contentType, f.ext
+		}
+	}
+	if contentType != "application/json" {
+		contentType += "; charset=utf-8"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="todos.`+ext+`"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	encode := encoders[format]
+	statuses := h.workflow.Statuses
+	log := logger.FromContext(c.Context(), h.logr)
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		if err := encode(w, todos, statuses); err != nil {
+			log.Warn("export interrupted", logger.Field("format", format), logger.Field("error", err))
+			return
+		}
+		w.Flush()
+	})
+}
@@ -1,28 +1,103 @@
 package todo
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/apperr"
 	"github.com/developwithayush/go-todo-app/internal/dto"
+	"github.com/developwithayush/go-todo-app/internal/jobs"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/realtime"
 	"github.com/developwithayush/go-todo-app/internal/util"
 	"github.com/gofiber/fiber/v3"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const heartbeatInterval = 25 * time.Second
+
+var (
+	errInvalidSession = apperr.Unauthorized("invalid_session", "Invalid user session")
+	errInvalidUserID  = apperr.Validation("invalid_user_id", "Invalid user ID")
+	errInvalidTodoID  = apperr.Validation("invalid_todo_id", "Invalid todo ID",
+		apperr.FieldError{Field: "id", Code: "objectid", Message: "ID must be a 24 character hex string"})
+	errUnsupportedPatch = apperr.New(apperr.KindUnsupportedMediaType, "unsupported_patch_format", "Unsupported patch format")
+	errInvalidQuery     = apperr.Validation("invalid_query", "Invalid query parameters")
+	errInvalidEventID   = apperr.Validation("invalid_event_id", "Invalid Last-Event-ID",
+		apperr.FieldError{Field: "Last-Event-ID", Code: "stream_id", Message: "Last-Event-ID must be an event ID such as 1700000000000-0"})
 )
 
 type Handler struct {
-	repo Repository
-	logr logger.Logger
+	repo     Repository
+	events   *realtime.Hub
+	workflow Workflow
+	jobs     *jobs.Runner
+	logr     logger.Logger
 }
 
-func NewHandler(repo Repository, logr logger.Logger) *Handler {
+func NewHandler(repo Repository, events *realtime.Hub, workflow Workflow, jobs *jobs.Runner, logr logger.Logger) *Handler {
 	return &Handler{
-		repo: repo,
-		logr: logr,
+		repo:     repo,
+		events:   events,
+		workflow: workflow,
+		jobs:     jobs,
+		logr:     logr,
+	}
+}
+
+// ifMatch checks the If-Match precondition and returns the version the
+// write has to be conditional on, or 0 when the request has none.
+func (h *Handler) ifMatch(ctx context.Context, c fiber.Ctx, userID, todoID primitive.ObjectID) (int64, error) {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return 0, nil
+	}
+
+	current, err := h.repo.FindByID(ctx, userID, todoID)
+	if err != nil {
+		return 0, err
+	}
+	if !util.MatchETag(header, util.ETag(current.Version)) {
+		return 0, ErrVersionMismatch
+	}
+	return current.Version, nil
+}
+
+// currentUser returns the ID of the user AuthRequired signed in.
+func currentUser(c fiber.Ctx) (primitive.ObjectID, error) {
+	id, ok := c.Locals("userID").(string)
+	if !ok {
+		return primitive.NilObjectID, errInvalidSession
+	}
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, errInvalidUserID
+	}
+	return userID, nil
+}
+
+// bindQuery binds and validates the query string into out.
+func bindQuery(c fiber.Ctx, out any) error {
+	err := c.Bind().Query(out)
+	var e *apperr.Error
+	if err == nil || errors.As(err, &e) {
+		return err
+	}
+	return errInvalidQuery.WithCause(err)
+}
+
+// publish notifies the user's connected clients. Delivery is best effort,
+// so failures are logged rather than returned to the caller.
+func (h *Handler) publish(ctx context.Context, userID primitive.ObjectID, eventType string, data interface{}) {
+	if err := h.events.Publish(ctx, userID.Hex(), eventType, data); err != nil {
+		logger.FromContext(ctx, h.logr).Warn("failed to publish todo event",
+			logger.Field("event", eventType),
+			logger.Field("error", err),
+		)
 	}
 }
 
@@ -33,88 +108,245 @@ func NewHandler(repo Repository, logr logger.Logger) *Handler {
 // @Accept json
 // @Produce json
 // @Security CookieAuth
+// @Param If-None-Match header string false "List ETag from a previous response"
 // @Success 200 {object} dto.TodoListResponse "List of todos"
+// @Success 304 "List unchanged since the given ETag"
+// @Header 200 {string} ETag "Version of the user's todo list"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
 // @Failure 500 {object} dto.ErrorResponse "Internal server error"
 // @Router /todos [get]
 func (h *Handler) ListTodos(c fiber.Ctx) error {
-	userIdString, ok := c.Locals("userID").(string)
-	if !ok {
-		return util.Error(c, fiber.StatusUnauthorized, "Invalid user session")
-	}
-	userID, err := primitive.ObjectIDFromHex(userIdString)
+	userID, err := currentUser(c)
 	if err != nil {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
 
+	// every write bumps the user's change sequence, so it doubles as the
+	// version of the whole list
+	seq, err := h.repo.CurrentSeq(ctx, userID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to list todos")
+	}
+	etag := util.WeakETag(seq)
+	c.Set(fiber.HeaderETag, etag)
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && util.MatchETag(match, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
 	todos, err := h.repo.ListByUser(ctx, userID)
 	if err != nil {
-		return util.Error(c, fiber.StatusInternalServerError, "Failed to list todos")
+		return apperr.Wrap(err, "Failed to list todos")
 	}
+	h.workflow.Normalize(todos)
 
 	return util.OK(c, todos)
 }
 
+// GetBoard godoc
+// @Summary Get todos as a board
+// @Description Retrieves the authenticated user's todos grouped into one column per workflow status, in workflow order. Todos within a column are sorted by position. The workflow is configured for the whole deployment; per-project workflows are not supported.
+// @Tags Todos
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} dto.BoardResponse "Board columns"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 500 {object} dto.ErrorResponse "Failed to load board"
+// @Router /todos/board [get]
+func (h *Handler) GetBoard(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	todos, err := h.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to load board")
+	}
+	h.workflow.Normalize(todos)
+
+	columns := make([]Column, 0, len(h.workflow.Statuses))
+	index := make(map[string]int, len(h.workflow.Statuses))
+	for _, status := range h.workflow.Statuses {
+		index[status] = len(columns)
+		columns = append(columns, Column{Status: status, Todos: []Todo{}})
+	}
+	for _, t := range todos {
+		i, ok := index[t.Status]
+		if !ok {
+			// statuses dropped from the workflow still get a column
+			i = len(columns)
+			index[t.Status] = i
+			columns = append(columns, Column{Status: t.Status, Todos: []Todo{}})
+		}
+		columns[i].Todos = append(columns[i].Todos, t)
+	}
+
+	return util.OK(c, columns)
+}
+
 // CreateTodo godoc
 // @Summary Create a new todo
-// @Description Creates a new todo item for the authenticated user
+// @Description Creates a new todo item for the authenticated user. Status defaults to the workflow's initial status.
 // @Tags Todos
 // @Accept json
 // @Produce json
 // @Security CookieAuth
 // @Param request body dto.CreateTodoRequest true "Todo details"
 // @Success 200 {object} dto.TodoCreateResponse "Created todo"
+// @Header 200 {string} ETag "Version of the created todo"
 // @Failure 400 {object} dto.ErrorResponse "Invalid request body"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
 // @Failure 500 {object} dto.ErrorResponse "Failed to create todo"
 // @Router /todos [post]
 func (h *Handler) CreateTodo(c fiber.Ctx) error {
-	userIdString, ok := c.Locals("userID").(string)
-	if !ok {
-		return util.Error(c, fiber.StatusUnauthorized, "Invalid user session")
-	}
-	userID, err := primitive.ObjectIDFromHex(userIdString)
+	userID, err := currentUser(c)
 	if err != nil {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 	var body dto.CreateTodoRequest
 
-	if err := c.Bind().Body(&body); err != nil || body.Title == "" {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	if err := c.Bind().Body(&body); err != nil {
+		return apperr.InvalidBody(err)
 	}
 
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
 
-	var last Todo
-	opts := options.FindOne().SetSort(bson.M{"position": -1})
-	err = db.Todos.FindOne(ctx, bson.M{"userId": userID}, opts).Decode(&last)
-	position := 0
-	if err == nil {
-		position = last.Position + 1
+	todo, err := h.newTodo(ctx, userID, primitive.NewObjectID(), body.Title, body.Description, body.Status)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to create todo")
 	}
+	todo.Color, todo.Recurrence, todo.Timezone = body.Color, body.Recurrence, body.Timezone
 
-	todo := Todo{
-		ID:          primitive.NewObjectID(),
-		UserID:      userID,
-		Title:       body.Title,
-		Description: body.Description,
-		Completed:   false,
-		Position:    position,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+	created, err := h.repo.Create(ctx, todo)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to create todo")
 	}
+	h.publish(ctx, userID, realtime.EventTodoCreated, created)
+
+	c.Set(fiber.HeaderETag, util.ETag(created.Version))
+	return util.OK(c, created)
+}
+
+// GetTodo godoc
+// @Summary Get a todo
+// @Description Retrieves a single todo item belonging to the authenticated user
+// @Tags Todos
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Todo ID" example(507f1f77bcf86cd799439011)
+// @Param If-None-Match header string false "ETag from a previous response"
+// @Success 200 {object} dto.TodoCreateResponse "The todo"
+// @Success 304 "Todo unchanged since the given ETag"
+// @Header 200 {string} ETag "Version of the todo"
+// @Failure 400 {object} dto.ErrorResponse "Invalid todo ID"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 404 {object} dto.ErrorResponse "Todo not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to get todo"
+// @Router /todos/{id} [get]
+func (h *Handler) GetTodo(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	todoID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return errInvalidTodoID
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
 
-	_, err = h.repo.Create(ctx, todo)
+	todo, err := h.repo.FindByID(ctx, userID, todoID)
 	if err != nil {
-		return util.Error(c, fiber.StatusInternalServerError, "Failed to create todo")
+		return apperr.Wrap(err, "Failed to get todo")
 	}
+	todo.Status = h.workflow.StatusOf(todo)
 
+	etag := util.ETag(todo.Version)
+	c.Set(fiber.HeaderETag, etag)
+	if match := c.Get(fiber.HeaderIfNoneMatch); match != "" && util.MatchETag(match, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
 	return util.OK(c, todo)
 }
 
+// PatchTodo godoc
+// @Summary Partially update a todo
+// @Description Applies a JSON Merge Patch (RFC 7396, application/merge-patch+json or application/json) or a JSON Patch (RFC 6902, application/json-patch+json) to the todo's title, description, status, completed and position. In a merge patch, null clears a field. Setting completed moves the todo to the done status, or back to the initial status.
+// @Tags Todos
+// @Accept json
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Todo ID" example(507f1f77bcf86cd799439011)
+// @Param If-Match header string false "Only update if the todo still has this ETag"
+// @Param request body dto.PatchTodoRequest true "Merge patch, or an array of JSON Patch operations"
+// @Success 200 {object} dto.TodoCreateResponse "Updated todo"
+// @Header 200 {string} ETag "New version of the todo"
+// @Failure 400 {object} dto.ErrorResponse "Invalid patch or todo ID"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 404 {object} dto.ErrorResponse "Todo not found"
+// @Failure 412 {object} dto.ErrorResponse "Todo was modified since the given ETag"
+// @Failure 415 {object} dto.ErrorResponse "Unsupported patch format"
+// @Failure 500 {object} dto.ErrorResponse "Failed to update todo"
+// @Router /todos/{id} [patch]
+func (h *Handler) PatchTodo(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	todoID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return errInvalidTodoID
+	}
+
+	mediaType, _, _ := strings.Cut(c.Get(fiber.HeaderContentType), ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	if mediaType != mimeMergePatch && mediaType != mimeJSONPatch && mediaType != fiber.MIMEApplicationJSON {
+		return errUnsupportedPatch
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	current, err := h.repo.FindByID(ctx, userID, todoID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to update todo")
+	}
+	if match := c.Get(fiber.HeaderIfMatch); match != "" && !util.MatchETag(match, util.ETag(current.Version)) {
+		return apperr.Wrap(ErrVersionMismatch, "Failed to update todo")
+	}
+
+	current.Status = h.workflow.StatusOf(current)
+
+	before := patchableOf(current)
+	patched, err := applyPatch(before, mediaType, c.Body())
+	if err != nil {
+		return apperr.Wrap(err, "Failed to update todo")
+	}
+
+	updated, changed, err := h.applyChanges(ctx, current, changesFromPatch(before, patched))
+	if err != nil {
+		return apperr.Wrap(err, "Failed to update todo")
+	}
+	if changed {
+		h.publish(ctx, userID, realtime.EventTodoUpdated, updated)
+	}
+
+	c.Set(fiber.HeaderETag, util.ETag(updated.Version))
+	return util.OK(c, updated)
+}
+
 // UpdateTodo godoc
 // @Summary Update a todo
 // @Description Updates an existing todo item for the authenticated user
@@ -123,43 +355,59 @@ func (h *Handler) CreateTodo(c fiber.Ctx) error {
 // @Produce json
 // @Security CookieAuth
 // @Param id path string true "Todo ID" example(507f1f77bcf86cd799439011)
+// @Param If-Match header string false "Only update if the todo still has this ETag"
 // @Param request body dto.UpdateTodoRequest true "Updated todo details"
 // @Success 200 {object} dto.MessageResponse "Todo updated successfully"
+// @Header 200 {string} ETag "New version of the todo"
 // @Failure 400 {object} dto.ErrorResponse "Invalid request body or todo ID"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 404 {object} dto.ErrorResponse "Todo not found"
+// @Failure 412 {object} dto.ErrorResponse "Todo was modified since the given ETag"
 // @Failure 500 {object} dto.ErrorResponse "Failed to update todo"
 // @Router /todos/{id} [put]
 func (h *Handler) UpdateTodo(c fiber.Ctx) error {
-	userIdString, ok := c.Locals("userID").(string)
-	if !ok {
-		return util.Error(c, fiber.StatusUnauthorized, "Invalid user session")
-	}
-	userID, err := primitive.ObjectIDFromHex(userIdString)
+	userID, err := currentUser(c)
 	if err != nil {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 	todoID, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid todo ID")
+		return errInvalidTodoID
 	}
 	var body dto.UpdateTodoRequest
-	if err := c.Bind().Body(&body); err != nil || body.Title == "" {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid request body")
+	if err := c.Bind().Body(&body); err != nil {
+		return apperr.InvalidBody(err)
 	}
 
-	update := bson.M{"updatedAt": time.Now()}
-	if body.Title != "" {
-		update["title"] = body.Title
-	}
-	if body.Description != "" {
-		update["description"] = body.Description
-	}
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
-	if err := h.repo.Update(ctx, userID, todoID, update); err != nil {
-		return util.Error(c, fiber.StatusInternalServerError, "Failed to update todo")
+
+	current, err := h.repo.FindByID(ctx, userID, todoID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to update todo")
+	}
+	if match := c.Get(fiber.HeaderIfMatch); match != "" && !util.MatchETag(match, util.ETag(current.Version)) {
+		return apperr.Wrap(ErrVersionMismatch, "Failed to update todo")
+	}
+
+	updated, changed, err := h.applyChanges(ctx, current, changes{
+		Title:       &body.Title,
+		Description: body.Description,
+		Status:      body.Status,
+		Color:       body.Color,
+		Recurrence:  body.Recurrence,
+		Timezone:    body.Timezone,
+		Completed:   body.Completed,
+	})
+	if err != nil {
+		return apperr.Wrap(err, "Failed to update todo")
+	}
+	if changed {
+		h.publish(ctx, userID, realtime.EventTodoUpdated, updated)
 	}
 
+	c.Set(fiber.HeaderETag, util.ETag(updated.Version))
 	return util.OK(c, "Todo updated successfully")
 }
 
@@ -171,28 +419,118 @@ func (h *Handler) UpdateTodo(c fiber.Ctx) error {
 // @Produce json
 // @Security CookieAuth
 // @Param id path string true "Todo ID" example(507f1f77bcf86cd799439011)
+// @Param If-Match header string false "Only delete if the todo still has this ETag"
 // @Success 200 {object} dto.MessageResponse "Todo deleted successfully"
 // @Failure 400 {object} dto.ErrorResponse "Invalid todo ID"
 // @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 404 {object} dto.ErrorResponse "Todo not found"
+// @Failure 412 {object} dto.ErrorResponse "Todo was modified since the given ETag"
 // @Failure 500 {object} dto.ErrorResponse "Failed to delete todo"
 // @Router /todos/{id} [delete]
 func (h *Handler) DeleteTodo(c fiber.Ctx) error {
-	userIdString, ok := c.Locals("userID").(string)
-	if !ok {
-		return util.Error(c, fiber.StatusUnauthorized, "Invalid user session")
-	}
-	userID, err := primitive.ObjectIDFromHex(userIdString)
+	userID, err := currentUser(c)
 	if err != nil {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid user ID")
+		return err
 	}
 	todoID, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
-		return util.Error(c, fiber.StatusBadRequest, "Invalid todo ID")
+		return errInvalidTodoID
 	}
 	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
 	defer cancel()
-	if err := h.repo.Delete(ctx, userID, todoID); err != nil {
-		return util.Error(c, fiber.StatusInternalServerError, "Failed to delete todo")
+	version, err := h.ifMatch(ctx, c, userID, todoID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to delete todo")
+	}
+	if err := h.repo.Delete(ctx, userID, todoID, version); err != nil {
+		return apperr.Wrap(err, "Failed to delete todo")
 	}
+	h.publish(ctx, userID, realtime.EventTodoDeleted, fiber.Map{"id": todoID})
 	return util.OK(c, "Todo deleted successfully")
 }
+
+// StreamTodos godoc
+// @Summary Stream todo changes
+// @Description Opens a Server-Sent Events stream of todo.created, todo.updated, todo.deleted and todo.reordered events for the authenticated user. Reconnecting clients send Last-Event-ID to receive the events they missed.
+// @Tags Todos
+// @Produce text/event-stream
+// @Security CookieAuth
+// @Param Last-Event-ID header string false "ID of the last event received" example(1700000000000-0)
+// @Success 200 {string} string "Event stream"
+// @Failure 400 {object} dto.ErrorResponse "Invalid Last-Event-ID"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Router /stream [get]
+func (h *Handler) StreamTodos(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	userIdString := userID.Hex()
+
+	lastID := c.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("lastEventId")
+	}
+	if lastID != "" && !realtime.ValidID(lastID) {
+		return errInvalidEventID
+	}
+
+	// subscribe before replaying so nothing published in between is lost
+	events, unsubscribe := h.events.Subscribe(userIdString)
+
+	var backlog []realtime.Event
+	if lastID != "" {
+		ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+		defer cancel()
+
+		var err error
+		backlog, err = h.events.Replay(ctx, userIdString, lastID)
+		if err != nil {
+			logger.FromContext(ctx, h.logr).Warn("failed to replay events", logger.Field("error", err))
+		}
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		replayed := realtime.NewReplayed(backlog)
+		for _, ev := range backlog {
+			writeEvent(w, ev)
+		}
+		fmt.Fprintf(w, "retry: %d\n\n", 3000)
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case ev, ok := <-events:
+				if !ok {
+					return
+				}
+				if replayed.Seen(ev) {
+					continue
+				}
+				writeEvent(w, ev)
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func writeEvent(w *bufio.Writer, ev realtime.Event) {
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Data)
+}
@@ -0,0 +1,90 @@
+package todo_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/developwithayush/go-todo-app/internal/domain/todo"
+	apphttp "github.com/developwithayush/go-todo-app/internal/http"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/realtime"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// handlerApp serves one handler method at path for user, with the app's
+// problem error handler.
+func handlerApp(repo todo.Repository, user primitive.ObjectID, method, path string, route func(*todo.Handler, fiber.Ctx) error) *fiber.App {
+	handler := todo.NewHandler(repo, realtime.NewHub(nil, logger.Nop()), todo.DefaultWorkflow(), nil, logger.Nop())
+	app := fiber.New(fiber.Config{ErrorHandler: apphttp.ErrorHandler})
+	app.Add([]string{method}, path, func(c fiber.Ctx) error {
+		c.Locals("userID", user.Hex())
+		return c.Next()
+	}, func(c fiber.Ctx) error {
+		return route(handler, c)
+	})
+	return app
+}
+
+func TestStreamRejectsInvalidLastEventID(t *testing.T) {
+	app := handlerApp(todo.NewMemoryRepository(), primitive.NewObjectID(), fiber.MethodGet, "/stream", (*todo.Handler).StreamTodos)
+
+	for _, id := range []string{"0-0) + (", "-", "1700000000000", "1700000000000-", "-1-0", "1700000000000-0-1", "$", "+"} {
+		req := httptest.NewRequest(fiber.MethodGet, "/stream", nil)
+		req.Header.Set("Last-Event-ID", id)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("GET /stream: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("Last-Event-ID %q: status %d, want 400", id, resp.StatusCode)
+		}
+	}
+}
+
+func TestPatchColorRecurrenceAndTimezone(t *testing.T) {
+	repo := todo.NewMemoryRepository()
+	user := primitive.NewObjectID()
+	created, err := repo.Create(context.Background(), todo.Todo{ID: primitive.NewObjectID(), UserID: user, Title: "Water plants", Status: "todo"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	app := handlerApp(repo, user, fiber.MethodPatch, "/todos/:id", (*todo.Handler).PatchTodo)
+
+	patch := func(body string) int {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodPatch, "/todos/"+created.ID.Hex(), strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, "application/merge-patch+json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("PATCH: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := patch(`{"color":"#2e8b57","recurrence":"FREQ=WEEKLY;BYDAY=SA","timezone":"Europe/Berlin"}`); status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	found, _ := repo.FindByID(context.Background(), user, created.ID)
+	if found.Color != "#2e8b57" || found.Recurrence != "FREQ=WEEKLY;BYDAY=SA" || found.Timezone != "Europe/Berlin" {
+		t.Fatalf("patched todo %+v", found)
+	}
+
+	for _, body := range []string{`{"color":"green"}`, `{"recurrence":"FREQ=SOMETIMES"}`, `{"timezone":"Europe/Nowhere"}`} {
+		if status := patch(body); status != fiber.StatusBadRequest {
+			t.Errorf("patch %s: status %d, want 400", body, status)
+		}
+	}
+
+	if status := patch(`{"recurrence":null,"timezone":null}`); status != fiber.StatusOK {
+		t.Fatalf("status %d, want 200", status)
+	}
+	found, _ = repo.FindByID(context.Background(), user, created.ID)
+	if found.Color != "#2e8b57" || found.Recurrence != "" || found.Timezone != "" {
+		t.Fatalf("todo after clearing the recurrence = %+v", found)
+	}
+}
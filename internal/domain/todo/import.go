@@ -0,0 +1,343 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/dto"
+	"github.com/developwithayush/go-todo-app/internal/jobs"
+	"github.com/developwithayush/go-todo-app/internal/realtime"
+	"github.com/developwithayush/go-todo-app/internal/util"
+	"github.com/developwithayush/go-todo-app/internal/validate"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ImportCreated = "created"
+	ImportValid   = "valid"
+	ImportSkipped = "skipped"
+	ImportFailed  = "failed"
+)
+
+const (
+	// importJobKind marks import jobs in the job store.
+	importJobKind = "todo_import"
+	// importMaxRows is the most rows one file may have.
+	importMaxRows = 10000
+	// importSyncRows is the most rows imported within the request; larger
+	// files run as a background job.
+	importSyncRows = 500
+	// importJobTimeout bounds a background import.
+	importJobTimeout = 10 * time.Minute
+)
+
+var (
+	errImportFormat   = apperr.New(apperr.KindUnsupportedMediaType, "unsupported_import_format", "Set format or send a JSON, CSV, Markdown or plain text body")
+	errImportEmpty    = apperr.Validation("empty_import", "Import file has no todos")
+	errImportTooLarge = apperr.Validation("too_many_rows", "Import file has too many todos")
+)
+
+// ImportRow is the outcome of one row of an import file. Row is the line
+// number, or the position in the array for JSON.
+type ImportRow struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	Title  string `json:"title,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportReport is the result of an import. In a dry run nothing is
+// written, rows that would be created are valid, and Created counts them.
+type ImportReport struct {
+	Format  string      `json:"format"`
+	DryRun  bool        `json:"dryRun"`
+	Total   int         `json:"total"`
+	Created int         `json:"created"`
+	Skipped int         `json:"skipped"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+type importOptions struct {
+	format string
+	dryRun bool
+	// dedupe is none, title (case-insensitive) or id (the id column of a
+	// file exported from this API)
+	dedupe string
+}
+
+// ImportTodos godoc
+// @Summary Import todos
+// @Description Creates todos from a file in the request body: JSON (an array of records as exported), CSV with a header row (title is required, id, description, status, completed, completed_at and created_at are optional), todo.txt or a Markdown checklist. The format comes from the format parameter or else from Content-Type.
+// @Description Rows are imported on their own: a row that fails or duplicates an existing todo (by title by default) is reported and the rest are still created. With dryRun=true nothing is written and the report shows what would happen.
+// @Description Files of more than 500 rows, or any file with async=true, are imported by a background job: the response is 202 with the job, and its Location is polled until the job has finished. Files may have at most 10000 rows.
+// @Tags Todos
+// @Accept json
+// @Accept text/csv
+// @Accept text/plain
+// @Accept text/markdown
+// @Produce json
+// @Security CookieAuth
+// @Param format query string false "File format, instead of Content-Type" Enums(json, csv, todotxt, markdown)
+// @Param dryRun query bool false "Report what would be imported without writing"
+// @Param dedupe query string false "Skip rows matching an existing todo or an earlier row" Enums(title, id, none) default(title)
+// @Param async query bool false "Run as a background job regardless of size"
+// @Param file body string true "Import file"
+// @Success 200 {object} dto.ImportResponse "Import report"
+// @Success 202 {object} dto.JobResponse "Import job started"
+// @Header 202 {string} Location "URL of the import job"
+// @Failure 400 {object} dto.ErrorResponse "Invalid file or options"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 415 {object} dto.ErrorResponse "Unknown format"
+// @Failure 500 {object} dto.ErrorResponse "Failed to import todos"
+// @Router /todos/import [post]
+func (h *Handler) ImportTodos(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	var query dto.ImportQuery
+	if err := bindQuery(c, &query); err != nil {
+		return err
+	}
+	opts := importOptions{format: query.Format, dryRun: query.DryRun, dedupe: query.Dedupe}
+	if opts.format == "" {
+		opts.format = formatOf(c.Get(fiber.HeaderContentType))
+	}
+	if opts.format == "" {
+		return errImportFormat
+	}
+	if opts.dedupe == "" {
+		opts.dedupe = "title"
+	}
+
+	// the body is only valid during the request, but the rows are copies
+	rows, err := decoders[opts.format](c.Body())
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(rows) == 0:
+		return errImportEmpty
+	case len(rows) > importMaxRows:
+		return errImportTooLarge
+	}
+
+	if !opts.dryRun && (query.Async || len(rows) > importSyncRows) {
+		job, err := h.jobs.Start(c.Context(), userID.Hex(), importJobKind, len(rows),
+			func(ctx context.Context, progress func(done, total int)) (any, error) {
+				ctx, cancel := context.WithTimeout(ctx, importJobTimeout)
+				defer cancel()
+				return h.importRows(ctx, userID, rows, opts, progress)
+			})
+		if err != nil {
+			return apperr.Wrap(err, "Failed to start import")
+		}
+		c.Location(c.Path() + "/jobs/" + job.ID)
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"success": true,
+			"data":    job,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	report, err := h.importRows(ctx, userID, rows, opts, nil)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to import todos")
+	}
+	return util.OK(c, report)
+}
+
+// GetImportJob godoc
+// @Summary Get an import job
+// @Description Returns the progress of a background import, and its report once it has succeeded. Jobs are kept for 24 hours.
+// @Tags Todos
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Job ID" example(65a1b2c3d4e5f6a7b8c9d0e1)
+// @Success 200 {object} dto.JobResponse "Import job"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 404 {object} dto.ErrorResponse "Job not found"
+// @Failure 500 {object} dto.ErrorResponse "Failed to load import job"
+// @Router /todos/import/jobs/{id} [get]
+func (h *Handler) GetImportJob(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
+	defer cancel()
+
+	job, err := h.jobs.Get(ctx, userID.Hex(), c.Params("id"))
+	if err == nil && job.Kind != importJobKind {
+		err = jobs.ErrNotFound
+	}
+	if err != nil {
+		return apperr.Wrap(err, "Failed to load import job")
+	}
+	return util.OK(c, job)
+}
+
+// formatOf picks the import format from a Content-Type header.
+func formatOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	for _, f := range formatTypes {
+		if f.contentType == mediaType {
+			return f.format
+		}
+	}
+	return ""
+}
+
+// importRows creates the todos of rows in order. Row problems are
+// reported in the result; an error means the import stopped, for example
+// because storage failed, and rows before it may have been created.
+func (h *Handler) importRows(ctx context.Context, userID primitive.ObjectID, rows []importRow, opts importOptions, progress func(done, total int)) (*ImportReport, error) {
+	report := &ImportReport{
+		Format: opts.format,
+		DryRun: opts.dryRun,
+		Total:  len(rows),
+		Rows:   make([]ImportRow, 0, len(rows)),
+	}
+
+	seen := map[string]bool{}
+	if opts.dedupe != "none" {
+		existing, err := h.repo.ListByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range existing {
+			seen[dedupeKey(opts.dedupe, t.ID.Hex(), t.Title)] = true
+		}
+	}
+
+	for i, row := range rows {
+		res, err := h.importRow(ctx, userID, row, opts, seen)
+		if err != nil {
+			return nil, err
+		}
+		report.Rows = append(report.Rows, res)
+		switch res.Status {
+		case ImportCreated, ImportValid:
+			report.Created++
+		case ImportSkipped:
+			report.Skipped++
+		case ImportFailed:
+			report.Failed++
+		}
+		if progress != nil {
+			progress(i+1, len(rows))
+		}
+	}
+	return report, nil
+}
+
+// importRow imports one row. Only errors that should stop the whole
+// import are returned.
+func (h *Handler) importRow(ctx context.Context, userID primitive.ObjectID, row importRow, opts importOptions, seen map[string]bool) (ImportRow, error) {
+	rec := row.Record
+	res := ImportRow{Row: row.Line, Title: rec.Title}
+	fail := func(err error) (ImportRow, error) {
+		res.Status = ImportFailed
+		res.Error = importError(err)
+		return res, nil
+	}
+
+	if row.Err != nil {
+		return fail(row.Err)
+	}
+	if err := validate.Struct(rec); err != nil {
+		return fail(err)
+	}
+	status := rec.Status
+	// a checkbox that disagrees with its section wins
+	if status == "" && h.workflow.Valid(row.Section) && h.workflow.IsDone(row.Section) == rec.Completed {
+		status = row.Section
+	}
+	if status == "" && rec.Completed {
+		status = h.workflow.Done[0]
+	}
+	if status != "" && !h.workflow.Valid(status) {
+		return fail(ErrInvalidStatus)
+	}
+
+	var key string
+	if opts.dedupe != "none" {
+		key = dedupeKey(opts.dedupe, rec.ID, rec.Title)
+		if seen[key] {
+			res.Status = ImportSkipped
+			res.Error = "duplicate " + opts.dedupe
+			return res, nil
+		}
+	}
+
+	todo, err := h.newTodo(ctx, userID, primitive.NewObjectID(), rec.Title, rec.Description, status)
+	if err != nil {
+		var e *apperr.Error
+		if errors.As(err, &e) && e.Kind != apperr.KindInternal {
+			return fail(err)
+		}
+		return res, err
+	}
+	// keep the history of todos moved from another tool
+	if rec.CreatedAt != nil {
+		todo.CreatedAt = *rec.CreatedAt
+	}
+	if todo.Completed && rec.CompletedAt != nil {
+		todo.CompletedAt = rec.CompletedAt
+	}
+
+	if key != "" {
+		seen[key] = true
+	}
+	if opts.dryRun {
+		res.Status = ImportValid
+		return res, nil
+	}
+
+	created, err := h.repo.Create(ctx, todo)
+	if err != nil {
+		return res, err
+	}
+	h.publish(ctx, userID, realtime.EventTodoCreated, created)
+	res.Status = ImportCreated
+	res.ID = created.ID.Hex()
+	return res, nil
+}
+
+// dedupeKey is what two todos share when they are duplicates. Rows without
+// an id are never duplicates by id.
+func dedupeKey(by, id, title string) string {
+	if by == "id" {
+		if id == "" {
+			return ""
+		}
+		return "id:" + id
+	}
+	return "title:" + strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// importError turns a row error into a client-facing message.
+func importError(err error) string {
+	var e *apperr.Error
+	if errors.As(err, &e) {
+		if len(e.Fields) > 0 {
+			return e.Fields[0].Message
+		}
+		return e.Message
+	}
+	// errors from reading the row, joined one per line
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
@@ -0,0 +1,274 @@
+package todo
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errDuplicateTodo = apperr.Conflict("todo_exists", "Todo already exists")
+
+// memoryRepo keeps todos in process memory. It behaves like the Mongo
+// repository and is meant for tests and local runs without a database.
+type memoryRepo struct {
+	mu         sync.Mutex
+	todos      map[primitive.ObjectID]Todo
+	tombstones map[tombstoneKey]Tombstone
+	seqs       map[primitive.ObjectID]int64
+}
+
+// tombstoneKey scopes a tombstone to its user, like the Mongo filter and
+// the SQL primary key.
+type tombstoneKey struct {
+	id, userID primitive.ObjectID
+}
+
+// memoryTxKey marks the ctx of a running memoryRepo transaction; its value
+// is the repository.
+type memoryTxKey struct{}
+
+func NewMemoryRepository() Repository {
+	return &memoryRepo{
+		todos:      map[primitive.ObjectID]Todo{},
+		tombstones: map[tombstoneKey]Tombstone{},
+		seqs:       map[primitive.ObjectID]int64{},
+	}
+}
+
+func (r *memoryRepo) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Todo, error) {
+	defer r.lock(ctx)()
+
+	todos := r.filter(func(t *Todo) bool { return t.UserID == userID })
+	sort.SliceStable(todos, func(i, j int) bool { return todos[i].Position < todos[j].Position })
+	return todos, nil
+}
+
+func (r *memoryRepo) FindByID(ctx context.Context, userID, todoID primitive.ObjectID) (*Todo, error) {
+	defer r.lock(ctx)()
+
+	todo, ok := r.todos[todoID]
+	if !ok || todo.UserID != userID {
+		return nil, ErrNotFound
+	}
+	todo = copyTodo(todo)
+	return &todo, nil
+}
+
+func (r *memoryRepo) Create(ctx context.Context, todo Todo) (*Todo, error) {
+	defer r.lock(ctx)()
+
+	if todo.ID.IsZero() {
+		todo.ID = primitive.NewObjectID()
+	}
+	if _, ok := r.todos[todo.ID]; ok {
+		return nil, errDuplicateTodo
+	}
+	for key := range r.tombstones {
+		if key.id == todo.ID && key.userID != todo.UserID {
+			return nil, ErrIDTaken
+		}
+	}
+	todo.Seq = r.nextSeq(todo.UserID)
+	todo.Version = 1
+
+	stored, err := storeTodo(todo, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.todos[todo.ID] = stored
+	stored = copyTodo(stored)
+	return &stored, nil
+}
+
+func (r *memoryRepo) Update(ctx context.Context, userID, todoID primitive.ObjectID, version int64, update bson.M) (*Todo, error) {
+	defer r.lock(ctx)()
+
+	current, err := r.match(userID, todoID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	set := maps.Clone(update)
+	set["seq"] = r.nextSeq(userID)
+	set["version"] = current.Version + 1
+	updated, err := storeTodo(current, set)
+	if err != nil {
+		return nil, err
+	}
+	r.todos[todoID] = updated
+	updated = copyTodo(updated)
+	return &updated, nil
+}
+
+func (r *memoryRepo) Delete(ctx context.Context, userID, todoID primitive.ObjectID, version int64) error {
+	defer r.lock(ctx)()
+
+	if _, err := r.match(userID, todoID, version); err != nil {
+		return err
+	}
+	delete(r.todos, todoID)
+	r.tombstones[tombstoneKey{todoID, userID}] = Tombstone{
+		ID:        todoID,
+		UserID:    userID,
+		Seq:       r.nextSeq(userID),
+		DeletedAt: time.Now(),
+	}
+	return nil
+}
+
+func (r *memoryRepo) NextPosition(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	defer r.lock(ctx)()
+
+	next := 0
+	for _, t := range r.todos {
+		if t.UserID == userID && t.Position >= next {
+			next = t.Position + 1
+		}
+	}
+	return next, nil
+}
+
+func (r *memoryRepo) CurrentSeq(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	defer r.lock(ctx)()
+
+	return r.seqs[userID], nil
+}
+
+func (r *memoryRepo) ListChangedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Todo, error) {
+	defer r.lock(ctx)()
+
+	todos := r.filter(func(t *Todo) bool { return t.UserID == userID && t.Seq > since })
+	sort.Slice(todos, func(i, j int) bool { return todos[i].Seq < todos[j].Seq })
+	if len(todos) > limit {
+		todos = todos[:limit]
+	}
+	return todos, nil
+}
+
+func (r *memoryRepo) ListDeletedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Tombstone, error) {
+	defer r.lock(ctx)()
+
+	tombstones := []Tombstone{}
+	for _, t := range r.tombstones {
+		if t.UserID == userID && t.Seq > since {
+			tombstones = append(tombstones, t)
+		}
+	}
+	sort.Slice(tombstones, func(i, j int) bool { return tombstones[i].Seq < tombstones[j].Seq })
+	if len(tombstones) > limit {
+		tombstones = tombstones[:limit]
+	}
+	return tombstones, nil
+}
+
+func (r *memoryRepo) FindTombstone(ctx context.Context, userID, todoID primitive.ObjectID) (*Tombstone, error) {
+	defer r.lock(ctx)()
+
+	tombstone, ok := r.tombstones[tombstoneKey{todoID, userID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &tombstone, nil
+}
+
+// WithTransaction holds the lock for the whole of fn, so other writers
+// wait for it and a rollback cannot undo their writes. The repository
+// calls fn makes with its ctx run under that lock; fn must not make them
+// concurrently. A transaction started inside fn joins the outer one.
+func (r *memoryRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memoryTxKey{}) == r {
+		return fn(ctx)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	todos, tombstones, seqs := maps.Clone(r.todos), maps.Clone(r.tombstones), maps.Clone(r.seqs)
+	if err := fn(context.WithValue(ctx, memoryTxKey{}, r)); err != nil {
+		r.todos, r.tombstones, r.seqs = todos, tombstones, seqs
+		return err
+	}
+	return nil
+}
+
+// lock takes r.mu unless ctx belongs to a transaction of r, which holds
+// it already, and returns the matching unlock.
+func (r *memoryRepo) lock(ctx context.Context) func() {
+	if ctx.Value(memoryTxKey{}) == r {
+		return func() {}
+	}
+	r.mu.Lock()
+	return r.mu.Unlock
+}
+
+// match returns the stored todo a conditional write applies to. Callers
+// hold the lock.
+func (r *memoryRepo) match(userID, todoID primitive.ObjectID, version int64) (Todo, error) {
+	todo, ok := r.todos[todoID]
+	if !ok || todo.UserID != userID {
+		return Todo{}, ErrNotFound
+	}
+	if version > 0 && todo.Version != version {
+		return Todo{}, ErrVersionMismatch
+	}
+	return todo, nil
+}
+
+// filter returns copies of the stored todos keep accepts. Callers hold the
+// lock.
+func (r *memoryRepo) filter(keep func(t *Todo) bool) []Todo {
+	todos := []Todo{}
+	for _, t := range r.todos {
+		if keep(&t) {
+			todos = append(todos, copyTodo(t))
+		}
+	}
+	// map order is random; ObjectIDs sort by creation time
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ID.Hex() < todos[j].ID.Hex() })
+	return todos
+}
+
+// nextSeq bumps the user's change sequence. Callers hold the lock.
+func (r *memoryRepo) nextSeq(userID primitive.ObjectID) int64 {
+	r.seqs[userID]++
+	return r.seqs[userID]
+}
+
+func copyTodo(t Todo) Todo {
+	t.History = slices.Clone(t.History)
+	if t.CompletedAt != nil {
+		at := *t.CompletedAt
+		t.CompletedAt = &at
+	}
+	return t
+}
+
+// storeTodo returns todo with set applied, passed through BSON so that it
+// is a deep copy with the same field handling and time precision as a
+// document read back from Mongo.
+func storeTodo(todo Todo, set bson.M) (Todo, error) {
+	raw, err := bson.Marshal(todo)
+	if err != nil {
+		return Todo{}, err
+	}
+	if len(set) > 0 {
+		var doc bson.M
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return Todo{}, err
+		}
+		maps.Copy(doc, set)
+		if raw, err = bson.Marshal(doc); err != nil {
+			return Todo{}, err
+		}
+	}
+	var out Todo
+	err = bson.Unmarshal(raw, &out)
+	return out, err
+}
@@ -0,0 +1,72 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMemoryRollbackKeepsConcurrentWrites(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	batchUser, otherUser := primitive.NewObjectID(), primitive.NewObjectID()
+	errAbort := errors.New("abort")
+
+	written := make(chan error, 1)
+	err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, sampleTodo(batchUser, "rolled back", 0)); err != nil {
+			return err
+		}
+		// another user writes while the batch is still running, then the
+		// batch fails
+		go func() {
+			_, err := repo.Create(context.Background(), sampleTodo(otherUser, "kept", 0))
+			written <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTransaction: got %v, want the error of fn", err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("concurrent Create: %v", err)
+	}
+
+	if todos, _ := repo.ListByUser(ctx, batchUser); len(todos) != 0 {
+		t.Fatalf("rolled back transaction left %s", titles(todos))
+	}
+	if todos, _ := repo.ListByUser(ctx, otherUser); titles(todos) != "kept" {
+		t.Fatalf("other user's todos after the rollback = %q, want kept", titles(todos))
+	}
+	if seq, _ := repo.CurrentSeq(ctx, otherUser); seq != 1 {
+		t.Fatalf("other user's seq = %d, want 1", seq)
+	}
+}
+
+func TestMemoryNestedTransactionJoins(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	user := primitive.NewObjectID()
+	errAbort := errors.New("abort")
+
+	err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, sampleTodo(user, "inner", 0))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTransaction: got %v, want the error of fn", err)
+	}
+	if todos, _ := repo.ListByUser(ctx, user); len(todos) != 0 {
+		t.Fatalf("outer rollback left %s", titles(todos))
+	}
+}
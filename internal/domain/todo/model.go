@@ -11,8 +11,45 @@ type Todo struct {
 	UserID      primitive.ObjectID `bson:"userId" json:"userId"`
 	Title       string             `bson:"title" json:"title"`
 	Description string             `bson:"description" json:"description"`
+	Status      string             `bson:"status" json:"status"`
+	Color       string             `bson:"color,omitempty" json:"color,omitempty"`
+	Recurrence  string             `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	Timezone    string             `bson:"timezone,omitempty" json:"timezone,omitempty"`
 	Completed   bool               `bson:"completed" json:"completed"`
+	CompletedAt *time.Time         `bson:"completedAt" json:"completedAt"`
+	History     []StatusChange     `bson:"history,omitempty" json:"history,omitempty"`
 	Position    int                `bson:"position" json:"position"`
+	Version     int64              `bson:"version" json:"version"`
+	Seq         int64              `bson:"seq" json:"seq"`
 	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
+
+// StatusChange is one entry in a todo's status history.
+type StatusChange struct {
+	From string    `bson:"from" json:"from"`
+	To   string    `bson:"to" json:"to"`
+	At   time.Time `bson:"at" json:"at"`
+}
+
+// Column is one status column of the board view.
+type Column struct {
+	Status string `json:"status"`
+	Todos  []Todo `json:"todos"`
+}
+
+// Tombstone records a deleted todo so offline clients can drop it on sync.
+type Tombstone struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    primitive.ObjectID `bson:"userId" json:"-"`
+	Seq       int64              `bson:"seq" json:"seq"`
+	DeletedAt time.Time          `bson:"deletedAt" json:"deletedAt"`
+}
+
+// Changes is everything that changed for a user after a sync token.
+type Changes struct {
+	Token   string      `json:"token"`
+	Todos   []Todo      `json:"todos"`
+	Deleted []Tombstone `json:"deleted"`
+	HasMore bool        `json:"hasMore"`
+}
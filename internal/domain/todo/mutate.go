@@ -0,0 +1,127 @@
+package todo
+
+import (
+	"context"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrTitleRequired = apperr.Validation("title_required", "Title is required",
+	apperr.FieldError{Field: "title", Code: "required", Message: "Title is required"})
+
+// changes lists the fields a write sets. Nil fields are left alone; an
+// explicit status wins over the legacy completed flag.
+type changes struct {
+	Title       *string
+	Description *string
+	Status      *string
+	Color       *string
+	Recurrence  *string
+	Timezone    *string
+	Completed   *bool
+	Position    *int
+}
+
+// newTodo builds a todo at the end of the user's list. An empty status
+// means the workflow's initial status.
+func (h *Handler) newTodo(ctx context.Context, userID, todoID primitive.ObjectID, title, description, status string) (Todo, error) {
+	if title == "" {
+		return Todo{}, ErrTitleRequired
+	}
+	if status == "" {
+		status = h.workflow.Initial
+	}
+	if !h.workflow.Valid(status) {
+		return Todo{}, ErrInvalidStatus
+	}
+
+	position, err := h.repo.NextPosition(ctx, userID)
+	if err != nil {
+		return Todo{}, err
+	}
+
+	now := time.Now()
+	todo := Todo{
+		ID:          todoID,
+		UserID:      userID,
+		Title:       title,
+		Description: description,
+		Status:      status,
+		Completed:   h.workflow.IsDone(status),
+		Position:    position,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if todo.Completed {
+		todo.CompletedAt = &now
+	}
+	return todo, nil
+}
+
+// applyChanges writes ch to current, conditional on the version that was
+// read. When nothing would change it returns current and false without
+// writing.
+func (h *Handler) applyChanges(ctx context.Context, current *Todo, ch changes) (*Todo, bool, error) {
+	current.Status = h.workflow.StatusOf(current)
+
+	now := time.Now()
+	update := bson.M{}
+	if ch.Title != nil && *ch.Title != current.Title {
+		if *ch.Title == "" {
+			return nil, false, ErrTitleRequired
+		}
+		update["title"] = *ch.Title
+	}
+	if ch.Description != nil && *ch.Description != current.Description {
+		update["description"] = *ch.Description
+	}
+	if ch.Color != nil && *ch.Color != current.Color {
+		update["color"] = *ch.Color
+	}
+	if ch.Recurrence != nil && *ch.Recurrence != current.Recurrence {
+		update["recurrence"] = *ch.Recurrence
+	}
+	if ch.Timezone != nil && *ch.Timezone != current.Timezone {
+		update["timezone"] = *ch.Timezone
+	}
+	if ch.Position != nil && *ch.Position != current.Position {
+		update["position"] = *ch.Position
+	}
+	transition, err := h.statusUpdate(current, ch.Status, ch.Completed, now)
+	if err != nil {
+		return nil, false, err
+	}
+	for k, v := range transition {
+		update[k] = v
+	}
+
+	if len(update) == 0 {
+		return current, false, nil
+	}
+	if _, ok := update["status"]; !ok {
+		// persists the derived status of todos stored before statuses
+		update["status"] = current.Status
+	}
+	update["updatedAt"] = now
+
+	updated, err := h.repo.Update(ctx, current.UserID, current.ID, current.Version, update)
+	if err != nil {
+		return nil, false, err
+	}
+	return updated, true, nil
+}
+
+// statusUpdate returns the fields to set for a requested status change.
+func (h *Handler) statusUpdate(current *Todo, status *string, completed *bool, now time.Time) (bson.M, error) {
+	switch {
+	case status != nil:
+		return h.workflow.Transition(current, *status, now)
+	case completed != nil:
+		return h.workflow.Transition(current, h.workflow.StatusForCompleted(current, *completed), now)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,113 @@
+package todo
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/validate"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+const (
+	mimeMergePatch = "application/merge-patch+json"
+	mimeJSONPatch  = "application/json-patch+json"
+)
+
+var errInvalidPatch = apperr.Validation("invalid_patch", "Invalid patch")
+
+// patchable is the client-editable view of a todo that patches are
+// applied to. Fields not listed here cannot be changed through PATCH.
+type patchable struct {
+	Title       string `json:"title" validate:"max=200"`
+	Description string `json:"description" validate:"max=2000"`
+	Status      string `json:"status" validate:"max=50"`
+	Color       string `json:"color" validate:"omitempty,hexcolor"`
+	Recurrence  string `json:"recurrence" validate:"omitempty,rrule"`
+	Timezone    string `json:"timezone" validate:"omitempty,timezone"`
+	Completed   bool   `json:"completed"`
+	Position    int    `json:"position" validate:"min=0"`
+}
+
+func patchableOf(t *Todo) patchable {
+	return patchable{
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      t.Status,
+		Color:       t.Color,
+		Recurrence:  t.Recurrence,
+		Timezone:    t.Timezone,
+		Completed:   t.Completed,
+		Position:    t.Position,
+	}
+}
+
+// applyPatch applies a merge patch or JSON Patch document to the view and
+// rejects results that touch fields outside it.
+func applyPatch(view patchable, mediaType string, body []byte) (patchable, error) {
+	doc, err := json.Marshal(view)
+	if err != nil {
+		return patchable{}, err
+	}
+
+	var out []byte
+	if mediaType == mimeJSONPatch {
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return patchable{}, errInvalidPatch
+		}
+		out, err = patch.Apply(doc)
+		if err != nil {
+			return patchable{}, errInvalidPatch
+		}
+	} else {
+		if !json.Valid(body) || bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+			return patchable{}, errInvalidPatch
+		}
+		out, err = jsonpatch.MergePatch(doc, body)
+		if err != nil {
+			return patchable{}, errInvalidPatch
+		}
+	}
+
+	var result patchable
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&result); err != nil {
+		return patchable{}, errInvalidPatch
+	}
+	if err := validate.Struct(result); err != nil {
+		return patchable{}, err
+	}
+	return result, nil
+}
+
+// changesFromPatch returns the fields the patch changed. A changed status
+// takes precedence over a changed completed flag.
+func changesFromPatch(before, after patchable) changes {
+	var ch changes
+	if after.Title != before.Title {
+		ch.Title = &after.Title
+	}
+	if after.Description != before.Description {
+		ch.Description = &after.Description
+	}
+	if after.Color != before.Color {
+		ch.Color = &after.Color
+	}
+	if after.Recurrence != before.Recurrence {
+		ch.Recurrence = &after.Recurrence
+	}
+	if after.Timezone != before.Timezone {
+		ch.Timezone = &after.Timezone
+	}
+	if after.Position != before.Position {
+		ch.Position = &after.Position
+	}
+	if after.Status != before.Status {
+		ch.Status = &after.Status
+	} else if after.Completed != before.Completed {
+		ch.Completed = &after.Completed
+	}
+	return ch
+}
@@ -2,67 +2,359 @@ package todo
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 
-	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/apperr"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Repository interface {
 	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Todo, error)
+	FindByID(ctx context.Context, userID, todoID primitive.ObjectID) (*Todo, error)
 	Create(ctx context.Context, todo Todo) (*Todo, error)
-	Update(ctx context.Context, userID, todoID primitive.ObjectID, update bson.M) error
-	Delete(ctx context.Context, userID, todoID primitive.ObjectID) error
-	UpdatePosition(ctx context.Context, userID primitive.ObjectID, position int) error
+	Update(ctx context.Context, userID, todoID primitive.ObjectID, version int64, update bson.M) (*Todo, error)
+	Delete(ctx context.Context, userID, todoID primitive.ObjectID, version int64) error
+	NextPosition(ctx context.Context, userID primitive.ObjectID) (int, error)
+
+	// change tracking for delta sync
+	CurrentSeq(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	ListChangedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Todo, error)
+	ListDeletedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Tombstone, error)
+	FindTombstone(ctx context.Context, userID, todoID primitive.ObjectID) (*Tombstone, error)
+
+	// WithTransaction runs fn so that its writes are applied all or nothing.
+	// fn may be retried and must only use the ctx it is given.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
-type repo struct{}
+var (
+	ErrNotFound        = apperr.NotFound("todo_not_found", "Todo not found")
+	ErrVersionMismatch = apperr.New(apperr.KindPreconditionFailed, "todo_modified", "Todo has been modified")
+	// ErrIDTaken is returned by Create for an ID another user's deleted
+	// todo had. IDs are never reused across users, so each tombstone has
+	// one owner whose clients pull it.
+	ErrIDTaken = apperr.Conflict("todo_id_taken", "Todo ID is already in use")
+	// ErrTransactionsUnsupported is returned by WithTransaction when the
+	// store cannot apply writes all or nothing, as on a standalone MongoDB.
+	ErrTransactionsUnsupported = apperr.New(apperr.KindNotImplemented, "transactions_unsupported", "Transactions are not supported by this database")
+)
+
+type repo struct {
+	client     *mongo.Client
+	database   *mongo.Database
+	todos      *mongo.Collection
+	tombstones *mongo.Collection
+	counters   *mongo.Collection
+
+	// txSupport caches whether the server runs transactions once known
+	txMu      sync.Mutex
+	txKnown   bool
+	txSupport bool
+}
 
-func NewRepository() Repository {
-	return &repo{}
+func NewRepository(database *mongo.Database) Repository {
+	return &repo{
+		client:     database.Client(),
+		database:   database,
+		todos:      database.Collection("todos"),
+		tombstones: database.Collection("todo_tombstones"),
+		counters:   database.Collection("counters"),
+	}
 }
 
 func (r *repo) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Todo, error) {
 	opt := options.Find().SetSort(bson.M{"position": 1})
 
-	cur, err := db.Todos.Find(ctx, bson.M{"userId": userID}, opt)
+	cur, err := r.todos.Find(ctx, bson.M{"userId": userID}, opt)
 	if err != nil {
 		return nil, err
 	}
 	defer cur.Close(ctx)
 
-	todos :=[]Todo{} // Initialize as empty slice, not nil
+	todos := []Todo{} // Initialize as empty slice, not nil
 	if err := cur.All(ctx, &todos); err != nil {
 		return nil, err
 	}
 	return todos, nil
 }
 
+func (r *repo) FindByID(ctx context.Context, userID, todoID primitive.ObjectID) (*Todo, error) {
+	var todo Todo
+	err := r.todos.FindOne(ctx, bson.M{"_id": todoID, "userId": userID}).Decode(&todo)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
 func (r *repo) Create(ctx context.Context, todo Todo) (*Todo, error) {
-	_, err := db.Todos.InsertOne(ctx, todo)
+	todo.Version = 1
+	err := r.atomically(ctx, func(ctx context.Context) error {
+		taken, err := r.tombstones.CountDocuments(ctx,
+			bson.M{"_id": todo.ID, "userId": bson.M{"$ne": todo.UserID}},
+			options.Count().SetLimit(1))
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrIDTaken
+		}
+
+		seq, err := r.nextSeq(ctx, todo.UserID)
+		if err != nil {
+			return err
+		}
+		todo.Seq = seq
+		_, err = r.todos.InsertOne(ctx, todo)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 	return &todo, nil
 }
 
-func (r *repo) Update(ctx context.Context, userID, todoID primitive.ObjectID, update bson.M) error {
-	_, err := db.Todos.UpdateOne(ctx,
-		bson.M{"_id": todoID, "userId": userID},
-		bson.M{"$set": update})
+// Update applies update and bumps the todo's version. A non-zero version
+// makes the write conditional on the stored version still matching.
+func (r *repo) Update(ctx context.Context, userID, todoID primitive.ObjectID, version int64, update bson.M) (*Todo, error) {
+	var todo Todo
+	err := r.atomically(ctx, func(ctx context.Context) error {
+		seq, err := r.nextSeq(ctx, userID)
+		if err != nil {
+			return err
+		}
+		set := bson.M{"seq": seq}
+		for k, v := range update {
+			set[k] = v
+		}
+
+		opt := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = r.todos.FindOneAndUpdate(ctx,
+			versionFilter(userID, todoID, version),
+			bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+			opt).Decode(&todo)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return r.missOrConflict(ctx, userID, todoID)
+		}
+		return err
+	})
 	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// Delete removes the todo and leaves a tombstone for sync. A non-zero
+// version makes the delete conditional like Update.
+func (r *repo) Delete(ctx context.Context, userID, todoID primitive.ObjectID, version int64) error {
+	return r.atomically(ctx, func(ctx context.Context) error {
+		seq, err := r.nextSeq(ctx, userID)
+		if err != nil {
+			return err
+		}
+		res, err := r.todos.DeleteOne(ctx, versionFilter(userID, todoID, version))
+		if err != nil {
+			return err
+		}
+		if res.DeletedCount == 0 {
+			return r.missOrConflict(ctx, userID, todoID)
+		}
+
+		_, err = r.tombstones.ReplaceOne(ctx,
+			bson.M{"_id": todoID, "userId": userID},
+			Tombstone{
+				ID:        todoID,
+				UserID:    userID,
+				Seq:       seq,
+				DeletedAt: time.Now(),
+			},
+			options.Replace().SetUpsert(true))
 		return err
+	})
+}
+
+// NextPosition returns the position after the user's last todo.
+func (r *repo) NextPosition(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var last Todo
+	opt := options.FindOne().SetSort(bson.M{"position": -1})
+	err := r.todos.FindOne(ctx, bson.M{"userId": userID}, opt).Decode(&last)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return last.Position + 1, nil
 }
 
-func (r *repo) Delete(ctx context.Context, userID, todoID primitive.ObjectID) error {
-	_, err := db.Todos.DeleteOne(ctx, bson.M{"_id": todoID, "userId": userID})
-	return err
+func (r *repo) CurrentSeq(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOne(ctx, bson.M{"_id": counterID(userID)}).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return counter.Seq, err
+}
+
+func (r *repo) ListChangedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Todo, error) {
+	opt := options.Find().SetSort(bson.M{"seq": 1}).SetLimit(int64(limit))
+
+	cur, err := r.todos.Find(ctx, bson.M{"userId": userID, "seq": bson.M{"$gt": since}}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	todos := []Todo{}
+	if err := cur.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+func (r *repo) ListDeletedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Tombstone, error) {
+	opt := options.Find().SetSort(bson.M{"seq": 1}).SetLimit(int64(limit))
+
+	cur, err := r.tombstones.Find(ctx, bson.M{"userId": userID, "seq": bson.M{"$gt": since}}, opt)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	tombstones := []Tombstone{}
+	if err := cur.All(ctx, &tombstones); err != nil {
+		return nil, err
+	}
+	return tombstones, nil
+}
+
+func (r *repo) FindTombstone(ctx context.Context, userID, todoID primitive.ObjectID) (*Tombstone, error) {
+	var tombstone Tombstone
+	err := r.tombstones.FindOne(ctx, bson.M{"_id": todoID, "userId": userID}).Decode(&tombstone)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tombstone, nil
 }
 
-func (r *repo) UpdatePosition(ctx context.Context, userID primitive.ObjectID, position int) error {
-	_, err := db.Todos.UpdateOne(ctx, bson.M{"userId": userID}, bson.M{"$set": bson.M{"position": position}})
+// WithTransaction needs MongoDB running as a replica set or sharded
+// cluster and returns ErrTransactionsUnsupported on a standalone server.
+func (r *repo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ok, err := r.transactional(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTransactionsUnsupported
+	}
+	return r.transaction(ctx, fn)
+}
+
+func (r *repo) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
 	return err
 }
+
+// atomically runs a write and the seq it allocates in one transaction, or
+// in the transaction ctx already carries. nextSeq comes first in it: a
+// second writer of the same user conflicts on the counter and is retried
+// after the first commits, so writes commit in the order of their seq and
+// a pull never passes one that commits later with a lower seq.
+//
+// Standalone servers cannot run transactions and get the writes one by
+// one, where a failed write leaves a gap in the sequence and concurrent
+// writers may commit out of order. Run a replica set in production.
+func (r *repo) atomically(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	// when the server cannot be asked, the write itself reports the outage
+	if ok, _ := r.transactional(ctx); !ok {
+		return fn(ctx)
+	}
+	return r.transaction(ctx, fn)
+}
+
+// transactional asks the server once whether it is a replica set member
+// or a mongos, which transactions need.
+func (r *repo) transactional(ctx context.Context) (bool, error) {
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+	if r.txKnown {
+		return r.txSupport, nil
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := r.database.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		// ask again next time
+		return false, err
+	}
+	r.txKnown = true
+	r.txSupport = hello.SetName != "" || hello.Msg == "isdbgrid"
+	return r.txSupport, nil
+}
+
+// nextSeq bumps the user's change sequence. Every write stamps the new
+// value on the document it touches, which is what delta sync reads back.
+func (r *repo) nextSeq(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	opt := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOneAndUpdate(ctx,
+		bson.M{"_id": counterID(userID)},
+		bson.M{"$inc": bson.M{"seq": 1}},
+		opt).Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+	return counter.Seq, nil
+}
+
+// missOrConflict tells apart a todo that doesn't exist from one whose
+// version moved on after a conditional write matched nothing.
+func (r *repo) missOrConflict(ctx context.Context, userID, todoID primitive.ObjectID) error {
+	if _, err := r.FindByID(ctx, userID, todoID); err != nil {
+		return err
+	}
+	return ErrVersionMismatch
+}
+
+func versionFilter(userID, todoID primitive.ObjectID, version int64) bson.M {
+	filter := bson.M{"_id": todoID, "userId": userID}
+	if version > 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+func counterID(userID primitive.ObjectID) string {
+	return "todos:" + userID.Hex()
+}
@@ -0,0 +1,352 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/db/dbtest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// repoBackends are the Repository implementations the contract runs
+// against. Backends that need a server skip themselves when it is not
+// configured.
+var repoBackends = []struct {
+	name string
+	new  func(t *testing.T) Repository
+}{
+	{"memory", func(t *testing.T) Repository { return NewMemoryRepository() }},
+	{"mongo", func(t *testing.T) Repository { return NewRepository(dbtest.Mongo(t)) }},
+	{"sqlite", func(t *testing.T) Repository { return NewSQLRepository(dbtest.SQLite(t)) }},
+	{"postgres", func(t *testing.T) Repository { return NewSQLRepository(dbtest.Postgres(t)) }},
+}
+
+var repoContract = []struct {
+	name string
+	run  func(t *testing.T, repo Repository)
+}{
+	{"create and find", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		todo := sampleTodo(user, "Buy milk", 0)
+		todo.Color, todo.Recurrence, todo.Timezone = "#1e90ff", "FREQ=WEEKLY;BYDAY=MO", "Europe/Berlin"
+		created := mustCreate(t, repo, todo)
+		if created.Version != 1 || created.Seq == 0 {
+			t.Fatalf("created version=%d seq=%d, want version 1 and a seq", created.Version, created.Seq)
+		}
+
+		found, err := repo.FindByID(ctx, user, created.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.Title != "Buy milk" || found.Status != StatusTodo || found.Version != 1 ||
+			found.Color != todo.Color || found.Recurrence != todo.Recurrence || found.Timezone != todo.Timezone {
+			t.Fatalf("found %+v", found)
+		}
+
+		if _, err := repo.FindByID(ctx, primitive.NewObjectID(), created.ID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("FindByID as another user: got %v, want ErrNotFound", err)
+		}
+		if _, err := repo.FindByID(ctx, user, primitive.NewObjectID()); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("FindByID of a missing todo: got %v, want ErrNotFound", err)
+		}
+	}},
+	{"list by user in position order", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+		todos, err := repo.ListByUser(ctx, user)
+		if err != nil || todos == nil || len(todos) != 0 {
+			t.Fatalf("ListByUser of a new user = %v, %v; want an empty, non-nil list", todos, err)
+		}
+
+		mustCreate(t, repo, sampleTodo(user, "second", 1))
+		mustCreate(t, repo, sampleTodo(user, "first", 0))
+		mustCreate(t, repo, sampleTodo(other, "someone else's", 0))
+
+		todos, err = repo.ListByUser(ctx, user)
+		if err != nil {
+			t.Fatalf("ListByUser: %v", err)
+		}
+		if got := titles(todos); got != "first,second" {
+			t.Fatalf("ListByUser = %s, want first,second", got)
+		}
+	}},
+	{"next position", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		if pos, err := repo.NextPosition(ctx, user); err != nil || pos != 0 {
+			t.Fatalf("NextPosition of a new user = %d, %v; want 0", pos, err)
+		}
+		mustCreate(t, repo, sampleTodo(user, "a", 4))
+		if pos, err := repo.NextPosition(ctx, user); err != nil || pos != 5 {
+			t.Fatalf("NextPosition = %d, %v; want 5", pos, err)
+		}
+	}},
+	{"conditional update", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		created := mustCreate(t, repo, sampleTodo(user, "draft", 0))
+
+		updated, err := repo.Update(ctx, user, created.ID, created.Version, bson.M{"title": "final", "status": StatusDone, "completed": true})
+		if err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if updated.Title != "final" || !updated.Completed || updated.Version != 2 || updated.Seq <= created.Seq {
+			t.Fatalf("updated %+v", updated)
+		}
+
+		cleared, err := repo.Update(ctx, user, created.ID, updated.Version, bson.M{"color": "#abc", "timezone": ""})
+		if err != nil {
+			t.Fatalf("Update color and timezone: %v", err)
+		}
+		if cleared.Color != "#abc" || cleared.Timezone != "" || cleared.Title != "final" {
+			t.Fatalf("updated %+v, want the new color, no timezone and the title kept", cleared)
+		}
+
+		if _, err := repo.Update(ctx, user, created.ID, created.Version, bson.M{"title": "stale"}); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("Update with a stale version: got %v, want ErrVersionMismatch", err)
+		}
+		if _, err := repo.Update(ctx, user, created.ID, 0, bson.M{"title": "forced"}); err != nil {
+			t.Fatalf("unconditional Update: %v", err)
+		}
+		if _, err := repo.Update(ctx, primitive.NewObjectID(), created.ID, 0, bson.M{"title": "x"}); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Update as another user: got %v, want ErrNotFound", err)
+		}
+	}},
+	{"delete leaves a tombstone", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		created := mustCreate(t, repo, sampleTodo(user, "old", 0))
+
+		if err := repo.Delete(ctx, user, created.ID, created.Version+1); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("Delete with a stale version: got %v, want ErrVersionMismatch", err)
+		}
+		if err := repo.Delete(ctx, user, created.ID, created.Version); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.FindByID(ctx, user, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("FindByID after Delete: got %v, want ErrNotFound", err)
+		}
+		if err := repo.Delete(ctx, user, created.ID, 0); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("second Delete: got %v, want ErrNotFound", err)
+		}
+
+		tombstone, err := repo.FindTombstone(ctx, user, created.ID)
+		if err != nil {
+			t.Fatalf("FindTombstone: %v", err)
+		}
+		if tombstone.Seq <= created.Seq {
+			t.Fatalf("tombstone seq %d not after the todo's %d", tombstone.Seq, created.Seq)
+		}
+		if _, err := repo.FindTombstone(ctx, primitive.NewObjectID(), created.ID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("FindTombstone as another user: got %v, want ErrNotFound", err)
+		}
+	}},
+	{"changes since a seq", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		a := mustCreate(t, repo, sampleTodo(user, "a", 0))
+		b := mustCreate(t, repo, sampleTodo(user, "b", 1))
+		c := mustCreate(t, repo, sampleTodo(user, "c", 2))
+		if _, err := repo.Update(ctx, user, a.ID, 0, bson.M{"title": "a2"}); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+		if err := repo.Delete(ctx, user, b.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		current, err := repo.CurrentSeq(ctx, user)
+		if err != nil {
+			t.Fatalf("CurrentSeq: %v", err)
+		}
+		if current < c.Seq+2 {
+			t.Fatalf("CurrentSeq = %d, want at least %d", current, c.Seq+2)
+		}
+
+		changed, err := repo.ListChangedSince(ctx, user, b.Seq, 10)
+		if err != nil {
+			t.Fatalf("ListChangedSince: %v", err)
+		}
+		if got := titles(changed); got != "c,a2" {
+			t.Fatalf("ListChangedSince = %s, want c,a2 in seq order", got)
+		}
+		limited, err := repo.ListChangedSince(ctx, user, 0, 1)
+		if err != nil || len(limited) != 1 || limited[0].Title != "c" {
+			t.Fatalf("ListChangedSince with limit 1 = %s, %v; want c", titles(limited), err)
+		}
+
+		deleted, err := repo.ListDeletedSince(ctx, user, 0, 10)
+		if err != nil || len(deleted) != 1 || deleted[0].ID != b.ID {
+			t.Fatalf("ListDeletedSince = %+v, %v; want b", deleted, err)
+		}
+		if deleted, err := repo.ListDeletedSince(ctx, user, current, 10); err != nil || len(deleted) != 0 {
+			t.Fatalf("ListDeletedSince the current seq = %+v, %v; want none", deleted, err)
+		}
+	}},
+	{"failed writes keep the seq", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		created := mustCreate(t, repo, sampleTodo(user, "a", 0))
+
+		if _, err := repo.Update(ctx, user, created.ID, created.Version+1, bson.M{"title": "stale"}); !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("Update with a stale version: got %v, want ErrVersionMismatch", err)
+		}
+		if err := repo.Delete(ctx, user, primitive.NewObjectID(), 0); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Delete of a missing todo: got %v, want ErrNotFound", err)
+		}
+		// a seq handed out by a write that did not happen would be a gap
+		// that a pull could never tell from a write still to commit
+		if current, err := repo.CurrentSeq(ctx, user); err != nil || current != created.Seq {
+			t.Fatalf("CurrentSeq = %d, %v; want %d", current, err, created.Seq)
+		}
+	}},
+	{"concurrent writes get distinct seqs", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		const writers = 8
+
+		var wg sync.WaitGroup
+		errs := make(chan error, writers)
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				created, err := repo.Create(ctx, sampleTodo(user, "t", i))
+				if err == nil {
+					_, err = repo.Update(ctx, user, created.ID, 0, bson.M{"title": "u"})
+				}
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("concurrent write: %v", err)
+			}
+		}
+
+		todos, err := repo.ListChangedSince(ctx, user, 0, 2*writers)
+		if err != nil {
+			t.Fatalf("ListChangedSince: %v", err)
+		}
+		seen := map[int64]bool{}
+		for _, todo := range todos {
+			if seen[todo.Seq] {
+				t.Fatalf("seq %d used twice", todo.Seq)
+			}
+			seen[todo.Seq] = true
+		}
+		if current, err := repo.CurrentSeq(ctx, user); err != nil || current != 2*writers {
+			t.Fatalf("CurrentSeq = %d, %v; want %d", current, err, 2*writers)
+		}
+	}},
+	{"tombstones belong to one user", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+		created := mustCreate(t, repo, sampleTodo(owner, "a", 0))
+		if err := repo.Delete(ctx, owner, created.ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		before, err := repo.FindTombstone(ctx, owner, created.ID)
+		if err != nil {
+			t.Fatalf("FindTombstone: %v", err)
+		}
+
+		reused := sampleTodo(other, "b", 0)
+		reused.ID = created.ID
+		if _, err := repo.Create(ctx, reused); !errors.Is(err, ErrIDTaken) {
+			t.Fatalf("Create with another user's deleted ID: got %v, want ErrIDTaken", err)
+		}
+		if _, err := repo.FindTombstone(ctx, other, created.ID); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("FindTombstone for the other user: got %v, want ErrNotFound", err)
+		}
+		deleted, err := repo.ListDeletedSince(ctx, owner, 0, 10)
+		if err != nil || len(deleted) != 1 || deleted[0].Seq != before.Seq {
+			t.Fatalf("owner's tombstones = %+v, %v; want the original one", deleted, err)
+		}
+	}},
+	{"transactions", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user := primitive.NewObjectID()
+		errAbort := errors.New("abort")
+
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			if _, err := repo.Create(ctx, sampleTodo(user, "rolled back", 0)); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if errors.Is(err, ErrTransactionsUnsupported) {
+			if todos, _ := repo.ListByUser(ctx, user); len(todos) != 0 {
+				t.Fatalf("WithTransaction without transactions ran fn and left %s", titles(todos))
+			}
+			t.Skip("transactions need a replica set")
+		}
+		if !errors.Is(err, errAbort) {
+			t.Fatalf("WithTransaction: got %v, want the error of fn", err)
+		}
+		if todos, _ := repo.ListByUser(ctx, user); len(todos) != 0 {
+			t.Fatalf("rolled back transaction left %s", titles(todos))
+		}
+
+		err = repo.WithTransaction(ctx, func(ctx context.Context) error {
+			_, err := repo.Create(ctx, sampleTodo(user, "committed", 0))
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithTransaction: %v", err)
+		}
+		if todos, _ := repo.ListByUser(ctx, user); titles(todos) != "committed" {
+			t.Fatalf("committed transaction left %s", titles(todos))
+		}
+	}},
+}
+
+func TestRepositoryContract(t *testing.T) {
+	for _, backend := range repoBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, tc := range repoContract {
+				t.Run(tc.name, func(t *testing.T) {
+					tc.run(t, backend.new(t))
+				})
+			}
+		})
+	}
+}
+
+func sampleTodo(userID primitive.ObjectID, title string, position int) Todo {
+	now := time.Now()
+	return Todo{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Title:     title,
+		Status:    StatusTodo,
+		Position:  position,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func mustCreate(t *testing.T, repo Repository, todo Todo) *Todo {
+	t.Helper()
+	created, err := repo.Create(context.Background(), todo)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return created
+}
+
+func titles(todos []Todo) string {
+	list := make([]string, len(todos))
+	for i, t := range todos {
+		list[i] = t.Title
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,375 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const todoColumns = `id, user_id, title, description, status, color, recurrence, timezone, completed, completed_at, history, position, version, seq, created_at, updated_at`
+
+// sqlColumns maps the field names used in updates to their columns.
+var sqlColumns = map[string]string{
+	"title":       "title",
+	"description": "description",
+	"status":      "status",
+	"color":       "color",
+	"recurrence":  "recurrence",
+	"timezone":    "timezone",
+	"completed":   "completed",
+	"completedAt": "completed_at",
+	"history":     "history",
+	"position":    "position",
+	"updatedAt":   "updated_at",
+}
+
+type sqlRepo struct {
+	db *db.SQL
+}
+
+// NewSQLRepository stores todos in PostgreSQL or SQLite.
+func NewSQLRepository(database *db.SQL) Repository {
+	return &sqlRepo{db: database}
+}
+
+func (r *sqlRepo) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]Todo, error) {
+	return r.queryTodos(ctx,
+		`SELECT `+todoColumns+` FROM todos WHERE user_id = ? ORDER BY position, id`,
+		userID.Hex())
+}
+
+func (r *sqlRepo) FindByID(ctx context.Context, userID, todoID primitive.ObjectID) (*Todo, error) {
+	row := r.db.Conn(ctx).QueryRowContext(ctx,
+		r.db.Rebind(`SELECT `+todoColumns+` FROM todos WHERE id = ? AND user_id = ?`),
+		todoID.Hex(), userID.Hex())
+	todo, err := scanTodo(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (r *sqlRepo) Create(ctx context.Context, todo Todo) (*Todo, error) {
+	if todo.ID.IsZero() {
+		todo.ID = primitive.NewObjectID()
+	}
+	todo.Version = 1
+	history, err := json.Marshal(historyOrEmpty(todo.History))
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.WithTx(ctx, func(ctx context.Context) error {
+		seq, err := r.nextSeq(ctx, todo.UserID)
+		if err != nil {
+			return err
+		}
+		todo.Seq = seq
+
+		var taken int
+		err = r.db.Conn(ctx).QueryRowContext(ctx,
+			r.db.Rebind(`SELECT COUNT(*) FROM todo_tombstones WHERE id = ? AND user_id <> ?`),
+			todo.ID.Hex(), todo.UserID.Hex()).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrIDTaken
+		}
+
+		_, err = r.db.Conn(ctx).ExecContext(ctx,
+			r.db.Rebind(`INSERT INTO todos (`+todoColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+			todo.ID.Hex(), todo.UserID.Hex(), todo.Title, todo.Description, todo.Status,
+			todo.Color, todo.Recurrence, todo.Timezone, todo.Completed,
+			nullTime(todo.CompletedAt), string(history), todo.Position, todo.Version, todo.Seq,
+			todo.CreatedAt.UTC(), todo.UpdatedAt.UTC())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (r *sqlRepo) Update(ctx context.Context, userID, todoID primitive.ObjectID, version int64, update bson.M) (*Todo, error) {
+	// sorted so the same update always produces the same statement
+	fields := make([]string, 0, len(update))
+	for field := range update {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	sets := make([]string, 0, len(fields)+2)
+	values := make([]any, 0, len(fields)+4)
+	for _, field := range fields {
+		column, ok := sqlColumns[field]
+		if !ok {
+			return nil, fmt.Errorf("todo: cannot update field %q", field)
+		}
+		value, err := sqlValue(update[field])
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, column+" = ?")
+		values = append(values, value)
+	}
+	sets = append(sets, "seq = ?", "version = version + 1")
+	query := `UPDATE todos SET ` + strings.Join(sets, ", ") + ` WHERE id = ? AND user_id = ?`
+	if version > 0 {
+		query += ` AND version = ?`
+	}
+	query += ` RETURNING ` + todoColumns
+
+	var todo Todo
+	err := r.db.WithTx(ctx, func(ctx context.Context) error {
+		seq, err := r.nextSeq(ctx, userID)
+		if err != nil {
+			return err
+		}
+		args := append(values, seq, todoID.Hex(), userID.Hex())
+		if version > 0 {
+			args = append(args, version)
+		}
+
+		todo, err = scanTodo(r.db.Conn(ctx).QueryRowContext(ctx, r.db.Rebind(query), args...))
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.missOrConflict(ctx, userID, todoID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (r *sqlRepo) Delete(ctx context.Context, userID, todoID primitive.ObjectID, version int64) error {
+	query := `DELETE FROM todos WHERE id = ? AND user_id = ?`
+	args := []any{todoID.Hex(), userID.Hex()}
+	if version > 0 {
+		query += ` AND version = ?`
+		args = append(args, version)
+	}
+
+	return r.db.WithTx(ctx, func(ctx context.Context) error {
+		seq, err := r.nextSeq(ctx, userID)
+		if err != nil {
+			return err
+		}
+		res, err := r.db.Conn(ctx).ExecContext(ctx, r.db.Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return r.missOrConflict(ctx, userID, todoID)
+		}
+
+		_, err = r.db.Conn(ctx).ExecContext(ctx,
+			r.db.Rebind(`INSERT INTO todo_tombstones (id, user_id, seq, deleted_at) VALUES (?, ?, ?, ?)
+ON CONFLICT (id, user_id) DO UPDATE SET seq = excluded.seq, deleted_at = excluded.deleted_at`),
+			todoID.Hex(), userID.Hex(), seq, time.Now().UTC())
+		return err
+	})
+}
+
+func (r *sqlRepo) NextPosition(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var last sql.NullInt64
+	err := r.db.Conn(ctx).QueryRowContext(ctx,
+		r.db.Rebind(`SELECT MAX(position) FROM todos WHERE user_id = ?`),
+		userID.Hex()).Scan(&last)
+	if err != nil {
+		return 0, err
+	}
+	if !last.Valid {
+		return 0, nil
+	}
+	return int(last.Int64) + 1, nil
+}
+
+func (r *sqlRepo) CurrentSeq(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var seq int64
+	err := r.db.Conn(ctx).QueryRowContext(ctx,
+		r.db.Rebind(`SELECT seq FROM counters WHERE id = ?`),
+		counterID(userID)).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return seq, err
+}
+
+func (r *sqlRepo) ListChangedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Todo, error) {
+	return r.queryTodos(ctx,
+		`SELECT `+todoColumns+` FROM todos WHERE user_id = ? AND seq > ? ORDER BY seq LIMIT ?`,
+		userID.Hex(), since, limit)
+}
+
+func (r *sqlRepo) ListDeletedSince(ctx context.Context, userID primitive.ObjectID, since int64, limit int) ([]Tombstone, error) {
+	rows, err := r.db.Conn(ctx).QueryContext(ctx,
+		r.db.Rebind(`SELECT id, user_id, seq, deleted_at FROM todo_tombstones WHERE user_id = ? AND seq > ? ORDER BY seq LIMIT ?`),
+		userID.Hex(), since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tombstones := []Tombstone{}
+	for rows.Next() {
+		tombstone, err := scanTombstone(rows)
+		if err != nil {
+			return nil, err
+		}
+		tombstones = append(tombstones, tombstone)
+	}
+	return tombstones, rows.Err()
+}
+
+func (r *sqlRepo) FindTombstone(ctx context.Context, userID, todoID primitive.ObjectID) (*Tombstone, error) {
+	row := r.db.Conn(ctx).QueryRowContext(ctx,
+		r.db.Rebind(`SELECT id, user_id, seq, deleted_at FROM todo_tombstones WHERE id = ? AND user_id = ?`),
+		todoID.Hex(), userID.Hex())
+	tombstone, err := scanTombstone(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tombstone, nil
+}
+
+func (r *sqlRepo) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.db.WithTx(ctx, fn)
+}
+
+func (r *sqlRepo) queryTodos(ctx context.Context, query string, args ...any) ([]Todo, error) {
+	rows, err := r.db.Conn(ctx).QueryContext(ctx, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	todos := []Todo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// nextSeq bumps the user's change sequence, creating the counter on first
+// use. Writes call it first in their transaction: the counter row stays
+// locked until commit, so a user's writes commit in the order of their
+// seq and a pull never passes one that commits later with a lower seq.
+// Taking it first also keeps the lock order the same for every write.
+func (r *sqlRepo) nextSeq(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	var seq int64
+	err := r.db.Conn(ctx).QueryRowContext(ctx,
+		r.db.Rebind(`INSERT INTO counters (id, seq) VALUES (?, 1)
+ON CONFLICT (id) DO UPDATE SET seq = counters.seq + 1 RETURNING seq`),
+		counterID(userID)).Scan(&seq)
+	return seq, err
+}
+
+func (r *sqlRepo) missOrConflict(ctx context.Context, userID, todoID primitive.ObjectID) error {
+	if _, err := r.FindByID(ctx, userID, todoID); err != nil {
+		return err
+	}
+	return ErrVersionMismatch
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTodo(s scanner) (Todo, error) {
+	var (
+		todo        Todo
+		id, userID  string
+		completedAt sql.NullTime
+		history     string
+	)
+	err := s.Scan(&id, &userID, &todo.Title, &todo.Description, &todo.Status,
+		&todo.Color, &todo.Recurrence, &todo.Timezone, &todo.Completed,
+		&completedAt, &history, &todo.Position, &todo.Version, &todo.Seq, &todo.CreatedAt, &todo.UpdatedAt)
+	if err != nil {
+		return Todo{}, err
+	}
+	if todo.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return Todo{}, err
+	}
+	if todo.UserID, err = primitive.ObjectIDFromHex(userID); err != nil {
+		return Todo{}, err
+	}
+	if completedAt.Valid {
+		todo.CompletedAt = &completedAt.Time
+	}
+	if err := json.Unmarshal([]byte(history), &todo.History); err != nil {
+		return Todo{}, err
+	}
+	if len(todo.History) == 0 {
+		todo.History = nil
+	}
+	return todo, nil
+}
+
+func scanTombstone(s scanner) (Tombstone, error) {
+	var (
+		tombstone  Tombstone
+		id, userID string
+	)
+	err := s.Scan(&id, &userID, &tombstone.Seq, &tombstone.DeletedAt)
+	if err != nil {
+		return Tombstone{}, err
+	}
+	if tombstone.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return Tombstone{}, err
+	}
+	if tombstone.UserID, err = primitive.ObjectIDFromHex(userID); err != nil {
+		return Tombstone{}, err
+	}
+	return tombstone, nil
+}
+
+// sqlValue converts an update value into one the drivers accept.
+func sqlValue(v any) (any, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v.UTC(), nil
+	case []StatusChange:
+		history, err := json.Marshal(historyOrEmpty(v))
+		return string(history), err
+	default:
+		return v, nil
+	}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func historyOrEmpty(history []StatusChange) []StatusChange {
+	if history == nil {
+		return []StatusChange{}
+	}
+	return history
+}
@@ -0,0 +1,379 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/dto"
+	"github.com/developwithayush/go-todo-app/internal/realtime"
+	"github.com/developwithayush/go-todo-app/internal/util"
+	"github.com/developwithayush/go-todo-app/internal/validate"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const syncPageSize = 500
+
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+const errTodoDeleted = "todo was deleted"
+
+var errInvalidSyncToken = apperr.Validation("invalid_sync_token", "Invalid sync token",
+	apperr.FieldError{Field: "since", Code: "sync_token", Message: "Sync token is not valid"})
+
+// SyncResult is the outcome of one client mutation. On conflict Todo holds
+// the server copy so the client can merge it.
+type SyncResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Todo   *Todo  `json:"todo,omitempty"`
+}
+
+// PullChanges godoc
+// @Summary Get changes since a sync token
+// @Description Returns todos created or updated and tombstones for todos deleted after the given change token, ordered by change sequence. Omit since for a full download. When hasMore is true, call again with the returned token.
+// @Tags Sync
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param since query string false "Change token from a previous sync" example(42)
+// @Success 200 {object} dto.SyncPullResponse "Changes since the token"
+// @Failure 400 {object} dto.ErrorResponse "Invalid sync token"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 500 {object} dto.ErrorResponse "Failed to load changes"
+// @Router /sync [get]
+func (h *Handler) PullChanges(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	since, err := parseSyncToken(c.Query("since"))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 10*time.Second)
+	defer cancel()
+
+	// read the counter first so anything written while we query is
+	// picked up again on the next pull rather than skipped
+	current, err := h.repo.CurrentSeq(ctx, userID)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to load changes")
+	}
+	todos, err := h.repo.ListChangedSince(ctx, userID, since, syncPageSize)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to load changes")
+	}
+	deleted, err := h.repo.ListDeletedSince(ctx, userID, since, syncPageSize)
+	if err != nil {
+		return apperr.Wrap(err, "Failed to load changes")
+	}
+	h.workflow.Normalize(todos)
+
+	return util.OK(c, collectChanges(todos, deleted, current, syncPageSize))
+}
+
+// PushChanges godoc
+// @Summary Apply offline changes
+// @Description Applies a batch of client mutations in order. An update or delete whose baseSeq is older than the server copy is reported as a conflict together with the server todo; other mutations are applied independently. Creates may carry a client-generated ID so retries are idempotent.
+// @Description The returned token is since, the token of the client's last pull, so the next pull also brings the changes other devices made in between. Without since it is the sequence from before the push.
+// @Tags Sync
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param request body dto.SyncPushRequest true "Client mutations"
+// @Success 200 {object} dto.SyncPushResponse "Per-mutation results"
+// @Failure 400 {object} dto.ErrorResponse "Invalid request body"
+// @Failure 401 {object} dto.ErrorResponse "Unauthorized - Invalid or missing authentication"
+// @Failure 403 {object} dto.ErrorResponse "CSRF check failed"
+// @Failure 500 {object} dto.ErrorResponse "Failed to apply changes"
+// @Router /sync [post]
+func (h *Handler) PushChanges(c fiber.Ctx) error {
+	userID, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+	var body dto.SyncPushRequest
+	if err := c.Bind().Body(&body); err != nil {
+		return apperr.InvalidBody(err)
+	}
+	since, err := parseSyncToken(body.Since)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), 30*time.Second)
+	defer cancel()
+
+	// The token handed back must not pass changes the client has not
+	// pulled yet, which the current sequence would once another device
+	// wrote since the last pull. Pushing from a stale token just means
+	// the next pull returns this push's own writes too.
+	if body.Since == "" {
+		if since, err = h.repo.CurrentSeq(ctx, userID); err != nil {
+			return apperr.Wrap(err, "Failed to apply changes")
+		}
+	}
+
+	results := make([]SyncResult, 0, len(body.Mutations))
+	for i, m := range body.Mutations {
+		res := h.applyMutation(ctx, userID, m)
+		res.Index = i
+		res.Op = m.Op
+		results = append(results, res)
+	}
+
+	return util.OK(c, fiber.Map{
+		"token":   formatSyncToken(since),
+		"results": results,
+	})
+}
+
+func (h *Handler) applyMutation(ctx context.Context, userID primitive.ObjectID, m dto.SyncMutation) SyncResult {
+	if err := validate.Struct(m); err != nil {
+		msg, ok := fieldMessage(err)
+		if !ok {
+			msg = "invalid mutation"
+		}
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: msg}
+	}
+
+	switch m.Op {
+	case "create":
+		return h.syncCreate(ctx, userID, m)
+	case "update":
+		return h.syncUpdate(ctx, userID, m)
+	case "delete":
+		return h.syncDelete(ctx, userID, m)
+	default:
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: "unknown op"}
+	}
+}
+
+func (h *Handler) syncCreate(ctx context.Context, userID primitive.ObjectID, m dto.SyncMutation) SyncResult {
+	if m.Title == nil || *m.Title == "" {
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: "title is required"}
+	}
+
+	todoID := primitive.NewObjectID()
+	if m.ID != "" {
+		id, err := primitive.ObjectIDFromHex(m.ID)
+		if err != nil {
+			return SyncResult{ID: m.ID, Status: SyncRejected, Error: "invalid todo ID"}
+		}
+		todoID = id
+
+		// a retried create must not produce a second copy
+		existing, err := h.repo.FindByID(ctx, userID, todoID)
+		if err == nil {
+			return SyncResult{ID: m.ID, Status: SyncApplied, Todo: existing}
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return SyncResult{ID: m.ID, Status: SyncRejected, Error: "failed to create todo"}
+		}
+		if _, err := h.repo.FindTombstone(ctx, userID, todoID); err == nil {
+			return SyncResult{ID: m.ID, Status: SyncConflict, Error: errTodoDeleted}
+		}
+	}
+
+	status := ""
+	switch {
+	case m.Status != nil:
+		status = *m.Status
+	case m.Completed != nil && *m.Completed:
+		status = h.workflow.Done[0]
+	}
+	description := ""
+	if m.Description != nil {
+		description = *m.Description
+	}
+
+	todo, err := h.newTodo(ctx, userID, todoID, *m.Title, description, status)
+	if err != nil {
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: err.Error()}
+	}
+
+	created, err := h.repo.Create(ctx, todo)
+	if errors.Is(err, ErrIDTaken) {
+		// another user deleted a todo with this ID; reusing it would
+		// mix up whose clients pull the deletion
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: ErrIDTaken.Message}
+	}
+	if err != nil {
+		return SyncResult{ID: todoID.Hex(), Status: SyncRejected, Error: "failed to create todo"}
+	}
+	h.publish(ctx, userID, realtime.EventTodoCreated, created)
+
+	return SyncResult{ID: todoID.Hex(), Status: SyncApplied, Todo: created}
+}
+
+func (h *Handler) syncUpdate(ctx context.Context, userID primitive.ObjectID, m dto.SyncMutation) SyncResult {
+	current, res, ok := h.syncLoad(ctx, userID, m)
+	if !ok {
+		return res
+	}
+
+	updated, changed, err := h.applyChanges(ctx, current, changes{
+		Title:       m.Title,
+		Description: m.Description,
+		Status:      m.Status,
+		Completed:   m.Completed,
+	})
+	if errors.Is(err, ErrVersionMismatch) {
+		return h.syncConflict(ctx, userID, m)
+	}
+	if errors.Is(err, ErrTitleRequired) || errors.Is(err, ErrInvalidStatus) {
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: err.Error()}
+	}
+	if err != nil {
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: "failed to update todo"}
+	}
+	if changed {
+		h.publish(ctx, userID, realtime.EventTodoUpdated, updated)
+	}
+
+	return SyncResult{ID: m.ID, Status: SyncApplied, Todo: updated}
+}
+
+func (h *Handler) syncDelete(ctx context.Context, userID primitive.ObjectID, m dto.SyncMutation) SyncResult {
+	current, res, ok := h.syncLoad(ctx, userID, m)
+	if !ok {
+		// deleting something that is already gone is what the client wanted
+		if res.Error == errTodoDeleted {
+			return SyncResult{ID: m.ID, Status: SyncApplied}
+		}
+		return res
+	}
+
+	err := h.repo.Delete(ctx, userID, current.ID, current.Version)
+	if errors.Is(err, ErrVersionMismatch) {
+		return h.syncConflict(ctx, userID, m)
+	}
+	if err != nil {
+		return SyncResult{ID: m.ID, Status: SyncRejected, Error: "failed to delete todo"}
+	}
+	h.publish(ctx, userID, realtime.EventTodoDeleted, fiber.Map{"id": current.ID})
+
+	return SyncResult{ID: m.ID, Status: SyncApplied}
+}
+
+// syncLoad fetches the todo a mutation targets and checks it has not
+// changed on the server since the client's base sequence.
+func (h *Handler) syncLoad(ctx context.Context, userID primitive.ObjectID, m dto.SyncMutation) (*Todo, SyncResult, bool) {
+	todoID, err := primitive.ObjectIDFromHex(m.ID)
+	if err != nil {
+		return nil, SyncResult{ID: m.ID, Status: SyncRejected, Error: "invalid todo ID"}, false
+	}
+
+	current, err := h.repo.FindByID(ctx, userID, todoID)
+	if errors.Is(err, ErrNotFound) {
+		if _, err := h.repo.FindTombstone(ctx, userID, todoID); err == nil {
+			return nil, SyncResult{ID: m.ID, Status: SyncConflict, Error: errTodoDeleted}, false
+		}
+		return nil, SyncResult{ID: m.ID, Status: SyncRejected, Error: "todo not found"}, false
+	}
+	if err != nil {
+		return nil, SyncResult{ID: m.ID, Status: SyncRejected, Error: "failed to load todo"}, false
+	}
+
+	current.Status = h.workflow.StatusOf(current)
+	if current.Seq > m.BaseSeq {
+		return nil, SyncResult{ID: m.ID, Status: SyncConflict, Error: "todo changed on the server", Todo: current}, false
+	}
+	return current, SyncResult{}, true
+}
+
+// syncConflict reports a todo that changed between loading it and
+// writing it back, returning the latest server copy.
+func (h *Handler) syncConflict(ctx context.Context, userID primitive.ObjectID, m dto.SyncMutation) SyncResult {
+	res := SyncResult{ID: m.ID, Status: SyncConflict, Error: "todo changed on the server"}
+	if todoID, err := primitive.ObjectIDFromHex(m.ID); err == nil {
+		res.Todo, _ = h.repo.FindByID(ctx, userID, todoID)
+	}
+	return res
+}
+
+// collectChanges merges todos and tombstones in sequence order and trims
+// the result to one page. When a page is cut short the token points at
+// the last change returned, otherwise at the user's current sequence.
+func collectChanges(todos []Todo, deleted []Tombstone, current int64, limit int) Changes {
+	type change struct {
+		seq  int64
+		todo *Todo
+		tomb *Tombstone
+	}
+
+	// a full page from either query may hide later entries of that kind,
+	// so nothing past the last one it returned can be sent yet
+	cutoff := int64(math.MaxInt64)
+	if len(todos) == limit {
+		cutoff = todos[len(todos)-1].Seq
+	}
+	if len(deleted) == limit && deleted[len(deleted)-1].Seq < cutoff {
+		cutoff = deleted[len(deleted)-1].Seq
+	}
+
+	all := make([]change, 0, len(todos)+len(deleted))
+	for i := range todos {
+		if todos[i].Seq <= cutoff {
+			all = append(all, change{seq: todos[i].Seq, todo: &todos[i]})
+		}
+	}
+	for i := range deleted {
+		if deleted[i].Seq <= cutoff {
+			all = append(all, change{seq: deleted[i].Seq, tomb: &deleted[i]})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].seq < all[j].seq })
+
+	out := Changes{
+		Token:   formatSyncToken(current),
+		Todos:   []Todo{},
+		Deleted: []Tombstone{},
+		HasMore: cutoff != math.MaxInt64,
+	}
+	if len(all) > limit {
+		all = all[:limit]
+		out.HasMore = true
+	}
+	if out.HasMore {
+		out.Token = formatSyncToken(all[len(all)-1].seq)
+	}
+	for _, ch := range all {
+		if ch.todo != nil {
+			out.Todos = append(out.Todos, *ch.todo)
+		} else {
+			out.Deleted = append(out.Deleted, *ch.tomb)
+		}
+	}
+	return out
+}
+
+// Sync tokens are opaque to clients; today they carry the change sequence.
+func parseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, errInvalidSyncToken
+	}
+	return seq, nil
+}
+
+func formatSyncToken(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
@@ -0,0 +1,133 @@
+package todo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/db/dbtest"
+	"github.com/developwithayush/go-todo-app/internal/domain/todo"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/gofiber/fiber/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pull runs a full sync for user and returns the changes.
+func pull(t *testing.T, repo todo.Repository, user primitive.ObjectID) todo.Changes {
+	t.Helper()
+	app := handlerApp(repo, user, fiber.MethodGet, "/sync", (*todo.Handler).PullChanges)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/sync", nil))
+	if err != nil {
+		t.Fatalf("GET /sync: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("GET /sync: status %d", resp.StatusCode)
+	}
+	var body struct {
+		Data todo.Changes `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return body.Data
+}
+
+func TestSyncReturnsTodosCreatedBeforeSeq(t *testing.T) {
+	database := dbtest.Mongo(t)
+	ctx := context.Background()
+	fresh, counted := primitive.NewObjectID(), primitive.NewObjectID()
+
+	// todos as written before delta sync: no seq, no version, and for the
+	// first user no change counter either
+	now := time.Now()
+	legacy := func(user primitive.ObjectID, title string, position int) bson.M {
+		return bson.M{"_id": primitive.NewObjectID(), "userId": user, "title": title, "status": "todo", "position": position, "createdAt": now, "updatedAt": now}
+	}
+	_, err := database.Collection("todos").InsertMany(ctx, []interface{}{
+		legacy(fresh, "second", 1),
+		legacy(fresh, "first", 0),
+		legacy(counted, "legacy", 3),
+		bson.M{"_id": primitive.NewObjectID(), "userId": counted, "title": "synced", "status": "todo", "position": 0, "version": int64(1), "seq": int64(5)},
+	})
+	if err != nil {
+		t.Fatalf("insert legacy todos: %v", err)
+	}
+	if _, err := database.Collection("counters").InsertOne(ctx, bson.M{"_id": "todos:" + counted.Hex(), "seq": int64(5)}); err != nil {
+		t.Fatalf("insert counter: %v", err)
+	}
+
+	if err := db.MigrateMongoUp(ctx, database, logger.Nop()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	repo := todo.NewRepository(database)
+
+	changes := pull(t, repo, fresh)
+	if len(changes.Todos) != 2 || changes.Todos[0].Title != "first" || changes.Todos[1].Title != "second" {
+		t.Fatalf("full sync = %+v, want both legacy todos in position order", changes.Todos)
+	}
+	if changes.Todos[0].Seq != 1 || changes.Todos[1].Seq != 2 || changes.Token != "2" {
+		t.Fatalf("seqs %d, %d and token %s, want 1, 2 and 2", changes.Todos[0].Seq, changes.Todos[1].Seq, changes.Token)
+	}
+
+	changes = pull(t, repo, counted)
+	if len(changes.Todos) != 2 || changes.Todos[1].Title != "legacy" || changes.Todos[1].Seq != 6 || changes.Token != "6" {
+		t.Fatalf("full sync = %+v, token %s; want the legacy todo after the counter at seq 6", changes.Todos, changes.Token)
+	}
+
+	created, err := repo.Create(ctx, todo.Todo{UserID: fresh, Title: "new", Status: "todo", Position: 2})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.Seq != 3 {
+		t.Fatalf("new todo seq = %d, want 3 after the backfilled ones", created.Seq)
+	}
+}
+
+func TestSyncPushRejectsAnotherUsersDeletedID(t *testing.T) {
+	repo := todo.NewMemoryRepository()
+	ctx := context.Background()
+	owner, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+	created, err := repo.Create(ctx, todo.Todo{ID: primitive.NewObjectID(), UserID: owner, Title: "a", Status: "todo"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Delete(ctx, owner, created.ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	app := handlerApp(repo, other, fiber.MethodPost, "/sync", (*todo.Handler).PushChanges)
+	body := `{"mutations":[{"op":"create","id":"` + created.ID.Hex() + `","title":"b"}]}`
+	req := httptest.NewRequest(fiber.MethodPost, "/sync", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("POST /sync: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		Data struct {
+			Results []todo.SyncResult `json:"results"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out.Data.Results) != 1 || out.Data.Results[0].Status != todo.SyncRejected {
+		t.Fatalf("results = %+v, want the create rejected", out.Data.Results)
+	}
+	if todos, _ := repo.ListByUser(ctx, other); len(todos) != 0 {
+		t.Fatalf("other user got %d todos", len(todos))
+	}
+	deleted, _ := repo.ListDeletedSince(ctx, owner, 0, 10)
+	if len(deleted) != 1 {
+		t.Fatalf("owner has %d tombstones, want 1", len(deleted))
+	}
+}
@@ -0,0 +1,475 @@
+package todo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/dto"
+)
+
+// Export and import formats.
+const (
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatTodoTxt  = "todotxt"
+	FormatMarkdown = "markdown"
+)
+
+// formatTypes are the content types of the formats, in the order an import
+// without a format parameter looks for them in Content-Type.
+var formatTypes = []struct{ format, contentType, ext string }{
+	{FormatJSON, "application/json", "json"},
+	{FormatCSV, "text/csv", "csv"},
+	{FormatMarkdown, "text/markdown", "md"},
+	{FormatTodoTxt, "text/plain", "txt"},
+}
+
+var csvColumns = []string{"id", "title", "description", "status", "completed", "completed_at", "created_at"}
+
+// csvFormulaPrefixes start cells that spreadsheets evaluate as formulas.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// toRecord returns the parts of t that are exported. Statuses must be
+// normalized.
+func toRecord(t Todo) dto.TodoRecord {
+	created := t.CreatedAt
+	return dto.TodoRecord{
+		ID:          t.ID.Hex(),
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      t.Status,
+		Completed:   t.Completed,
+		CompletedAt: t.CompletedAt,
+		CreatedAt:   &created,
+	}
+}
+
+// importRow is one parsed row of an import file. Err is set when the row
+// could not be read; the rest of the file is still imported. Section is
+// the heading a Markdown item is under, its status if the workflow has one
+// by that name.
+type importRow struct {
+	Line    int
+	Record  dto.TodoRecord
+	Section string
+	Err     error
+}
+
+// encoder writes todos in one format. Normalized statuses are expected;
+// statuses lists the workflow so grouped formats can order by it.
+type encoder func(w io.Writer, todos []Todo, statuses []string) error
+
+// decoder reads an import file. A returned error means the file as a whole
+// cannot be read.
+type decoder func(data []byte) ([]importRow, error)
+
+var encoders = map[string]encoder{
+	FormatJSON:     encodeJSON,
+	FormatCSV:      encodeCSV,
+	FormatTodoTxt:  encodeTodoTxt,
+	FormatMarkdown: encodeMarkdown,
+}
+
+var decoders = map[string]decoder{
+	FormatJSON:     decodeJSON,
+	FormatCSV:      decodeCSV,
+	FormatTodoTxt:  decodeTodoTxt,
+	FormatMarkdown: decodeMarkdown,
+}
+
+func invalidFile(format string, err error) error {
+	return apperr.Validation("invalid_import_file", "Import file is not valid "+format).WithCause(err)
+}
+
+// encodeJSON writes an array of records, one todo at a time.
+func encodeJSON(w io.Writer, todos []Todo, _ []string) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[")
+	for i, t := range todos {
+		if i > 0 {
+			bw.WriteString(",")
+		}
+		data, err := json.Marshal(toRecord(t))
+		if err != nil {
+			return err
+		}
+		bw.WriteString("\n  ")
+		bw.Write(data)
+	}
+	bw.WriteString("\n]\n")
+	return bw.Flush()
+}
+
+// jsonRecord reads the dates of a record as strings, so plain dates are
+// accepted as well as RFC 3339 times.
+type jsonRecord struct {
+	dto.TodoRecord
+	CompletedAt string `json:"completedAt"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+// decodeJSON reads an array of records. A record of the wrong shape fails
+// only its own row; unknown fields are ignored so lists from other tools
+// and this API's own responses can be imported. The row of a record is its
+// position in the array.
+func decodeJSON(data []byte) ([]importRow, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, invalidFile("JSON", errors.New("expected an array of todos"))
+	}
+	var rows []importRow
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, invalidFile("JSON", err)
+		}
+		row := importRow{Line: len(rows) + 1}
+		var rec jsonRecord
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			row.Err = jsonError(err)
+			rows = append(rows, row)
+			continue
+		}
+		row.Record = rec.TodoRecord
+		var errs [2]error
+		row.Record.CompletedAt, errs[0] = parseTime("completedAt", rec.CompletedAt)
+		row.Record.CreatedAt, errs[1] = parseTime("createdAt", rec.CreatedAt)
+		row.Err = errors.Join(errs[:]...)
+		rows = append(rows, row)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, invalidFile("JSON", err)
+	}
+	return rows, nil
+}
+
+func jsonError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		return errors.New("todo must be an object")
+	}
+	switch typeErr.Type.Kind() {
+	case reflect.String:
+		return fmt.Errorf("%s must be a string", typeErr.Field)
+	case reflect.Bool:
+		return fmt.Errorf("%s must be a boolean", typeErr.Field)
+	}
+	return fmt.Errorf("%s is invalid", typeErr.Field)
+}
+
+func encodeCSV(w io.Writer, todos []Todo, _ []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, t := range todos {
+		r := toRecord(t)
+		if err := cw.Write([]string{
+			r.ID,
+			csvCell(r.Title),
+			csvCell(r.Description),
+			r.Status,
+			strconv.FormatBool(r.Completed),
+			formatTime(r.CompletedAt),
+			formatTime(r.CreatedAt),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell quotes text that a spreadsheet would run as a formula. Imports
+// undo it.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// decodeCSV reads a file with a header row. Columns are matched by name,
+// ignoring case, spaces and underscores, so completed_at, completedAt and
+// "Completed At" are the same column. Other columns are ignored.
+func decodeCSV(data []byte) ([]importRow, error) {
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, invalidFile("CSV", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.NewReplacer("_", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		cols[name] = i
+	}
+	if _, ok := cols["title"]; !ok {
+		return nil, invalidFile("CSV", errors.New("the header row has no title column"))
+	}
+
+	var rows []importRow
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, invalidFile("CSV", err)
+		}
+		line, _ := cr.FieldPos(0)
+		get := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return strings.TrimSpace(fields[i])
+		}
+
+		row := importRow{Line: line, Record: dto.TodoRecord{
+			ID:          get("id"),
+			Title:       uncsvCell(get("title")),
+			Description: uncsvCell(get("description")),
+			Status:      get("status"),
+		}}
+		var errs []error
+		if v := get("completed"); v != "" {
+			row.Record.Completed, err = parseBool(v)
+			errs = append(errs, err)
+		}
+		row.Record.CompletedAt, err = parseTime("completed_at", get("completedat"))
+		errs = append(errs, err)
+		row.Record.CreatedAt, err = parseTime("created_at", get("createdat"))
+		errs = append(errs, err)
+		row.Err = errors.Join(errs...)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func uncsvCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "y", "1", "x":
+		return true, nil
+	case "false", "no", "n", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("completed must be true or false")
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates.
+func parseTime(field, s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be a date or an RFC 3339 time", field)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// encodeTodoTxt writes one todo.txt line per todo, with the status as a
+// status: tag. todo.txt has no room for multi-line text, so descriptions
+// are left out.
+func encodeTodoTxt(w io.Writer, todos []Todo, _ []string) error {
+	bw := bufio.NewWriter(w)
+	for _, t := range todos {
+		// the creation date may only follow a completion date
+		dated := true
+		if t.Completed {
+			bw.WriteString("x ")
+			if t.CompletedAt != nil {
+				bw.WriteString(t.CompletedAt.Format(time.DateOnly) + " ")
+			} else {
+				dated = false
+			}
+		}
+		if dated {
+			bw.WriteString(t.CreatedAt.Format(time.DateOnly) + " ")
+		}
+		fmt.Fprintf(bw, "%s status:%s\n", oneLine(t.Title), t.Status)
+	}
+	return bw.Flush()
+}
+
+var todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\) `)
+
+// decodeTodoTxt reads todo.txt lines: an optional x and completion date,
+// an optional (A) priority, an optional creation date, then the text.
+// A status: tag sets the status. Projects, contexts and other tags such as
+// due: stay in the title, where todo.txt keeps them; a priority becomes a
+// pri: tag.
+func decodeTodoTxt(data []byte) ([]importRow, error) {
+	var rows []importRow
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		row := importRow{Line: line}
+		rec := &row.Record
+
+		if rest, ok := strings.CutPrefix(text, "x "); ok {
+			rec.Completed = true
+			text = rest
+			if date, rest, ok := cutDate(text); ok {
+				rec.CompletedAt, text = &date, rest
+			}
+		}
+		var priority string
+		if m := todoTxtPriority.FindStringSubmatch(text); m != nil {
+			priority, text = m[1], text[len(m[0]):]
+		}
+		if date, rest, ok := cutDate(text); ok {
+			rec.CreatedAt, text = &date, rest
+		}
+
+		words := strings.Fields(text)
+		kept := words[:0]
+		for _, word := range words {
+			if status, ok := strings.CutPrefix(word, "status:"); ok && status != "" {
+				rec.Status = status
+				continue
+			}
+			kept = append(kept, word)
+		}
+		if priority != "" {
+			kept = append(kept, "pri:"+priority)
+		}
+		rec.Title = strings.Join(kept, " ")
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, invalidFile("todo.txt", err)
+	}
+	return rows, nil
+}
+
+// cutDate cuts a leading YYYY-MM-DD date and the space after it.
+func cutDate(s string) (time.Time, string, bool) {
+	word, rest, _ := strings.Cut(s, " ")
+	date, err := time.Parse(time.DateOnly, word)
+	if err != nil {
+		return time.Time{}, s, false
+	}
+	return date, rest, true
+}
+
+// encodeMarkdown writes a checklist with a section per status, in
+// workflow order. Descriptions are indented under their item.
+func encodeMarkdown(w io.Writer, todos []Todo, statuses []string) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# Todos\n")
+
+	order := append([]string{}, statuses...)
+	byStatus := map[string][]Todo{}
+	for _, t := range todos {
+		if _, ok := byStatus[t.Status]; !ok && !slices.Contains(order, t.Status) {
+			order = append(order, t.Status)
+		}
+		byStatus[t.Status] = append(byStatus[t.Status], t)
+	}
+	for _, status := range order {
+		list := byStatus[status]
+		if len(list) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "\n## %s\n\n", status)
+		for _, t := range list {
+			check := " "
+			if t.Completed {
+				check = "x"
+			}
+			fmt.Fprintf(bw, "- [%s] %s\n", check, oneLine(t.Title))
+			if t.Description != "" {
+				for _, line := range strings.Split(t.Description, "\n") {
+					bw.WriteString(strings.TrimRight("  "+line, " ") + "\n")
+				}
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+var markdownItem = regexp.MustCompile(`^[-*+] (?:\[([ xX])\] )?(.*)$`)
+
+// decodeMarkdown reads top-level list items, checked or not, as todos,
+// each with the heading it is under. Indented lines under an item are its
+// description.
+func decodeMarkdown(data []byte) ([]importRow, error) {
+	var rows []importRow
+	var section string
+	var current *importRow
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		raw := strings.TrimRight(sc.Text(), " \t\r")
+		text := strings.TrimSpace(raw)
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "#"):
+			section = strings.TrimSpace(strings.TrimLeft(text, "#"))
+			current = nil
+		case raw != text && current != nil:
+			// indented continuation of the last item
+			rec := &current.Record
+			if rec.Description != "" {
+				rec.Description += "\n"
+			}
+			rec.Description += strings.TrimPrefix(strings.TrimPrefix(raw, "  "), "\t")
+		default:
+			m := markdownItem.FindStringSubmatch(text)
+			if m == nil || raw != text {
+				current = nil
+				continue
+			}
+			rows = append(rows, importRow{Line: line, Section: section, Record: dto.TodoRecord{
+				Title:     strings.TrimSpace(m[2]),
+				Completed: m[1] == "x" || m[1] == "X",
+			}})
+			current = &rows[len(rows)-1]
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, invalidFile("Markdown", err)
+	}
+	return rows, nil
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
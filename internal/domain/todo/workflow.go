@@ -0,0 +1,146 @@
+package todo
+
+import (
+	"slices"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	StatusBacklog    = "backlog"
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+var ErrInvalidStatus = apperr.Validation("invalid_status", "Invalid status",
+	apperr.FieldError{Field: "status", Code: "oneof", Message: "Status is not part of the workflow"})
+
+// Workflow is the ordered set of statuses a todo moves through. The order
+// is also the column order of the board view. Done statuses mark a todo
+// as completed.
+//
+// There is one workflow for the whole deployment, set in the config.
+// Workflows per project are not supported: todos do not belong to a
+// project, so there is nothing to scope one to yet.
+type Workflow struct {
+	Statuses []string
+	Initial  string
+	Done     []string
+}
+
+func DefaultWorkflow() Workflow {
+	return Workflow{
+		Statuses: []string{StatusBacklog, StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+		Initial:  StatusTodo,
+		Done:     []string{StatusDone},
+	}
+}
+
+// NewWorkflow builds a workflow from the configured statuses, falling back
+// to the default workflow for anything left empty or inconsistent.
+func NewWorkflow(statuses []string, initial string, done []string) Workflow {
+	w := DefaultWorkflow()
+	if list := dedupe(statuses); len(list) > 0 {
+		w.Statuses = list
+		w.Initial = list[0]
+		w.Done = []string{list[len(list)-1]}
+	}
+	if initial != "" && w.Valid(initial) {
+		w.Initial = initial
+	}
+	if list := dedupe(done); len(list) > 0 {
+		valid := make([]string, 0, len(list))
+		for _, s := range list {
+			if w.Valid(s) {
+				valid = append(valid, s)
+			}
+		}
+		if len(valid) > 0 {
+			w.Done = valid
+		}
+	}
+	return w
+}
+
+func (w Workflow) Valid(status string) bool {
+	return slices.Contains(w.Statuses, status)
+}
+
+func (w Workflow) IsDone(status string) bool {
+	return slices.Contains(w.Done, status)
+}
+
+// StatusOf returns the todo's status, deriving it from Completed for todos
+// stored before statuses existed.
+func (w Workflow) StatusOf(t *Todo) string {
+	if t.Status != "" {
+		return t.Status
+	}
+	if t.Completed {
+		return w.Done[0]
+	}
+	return w.Initial
+}
+
+// Normalize fills in the status of todos stored before statuses existed.
+func (w Workflow) Normalize(todos []Todo) {
+	for i := range todos {
+		todos[i].Status = w.StatusOf(&todos[i])
+	}
+}
+
+// Transition returns the fields to set when moving the todo to status, or
+// nil when it is already there.
+func (w Workflow) Transition(t *Todo, status string, now time.Time) (bson.M, error) {
+	if !w.Valid(status) {
+		return nil, ErrInvalidStatus
+	}
+	from := w.StatusOf(t)
+	if from == status {
+		return nil, nil
+	}
+
+	set := bson.M{
+		"status":    status,
+		"completed": w.IsDone(status),
+		"history": append(slices.Clone(t.History), StatusChange{
+			From: from,
+			To:   status,
+			At:   now,
+		}),
+	}
+	switch {
+	case w.IsDone(status) && !w.IsDone(from):
+		set["completedAt"] = now
+	case !w.IsDone(status):
+		set["completedAt"] = nil
+	}
+	return set, nil
+}
+
+// StatusForCompleted maps the legacy completed flag onto the workflow.
+func (w Workflow) StatusForCompleted(t *Todo, completed bool) string {
+	current := w.StatusOf(t)
+	if completed == w.IsDone(current) {
+		return current
+	}
+	if completed {
+		return w.Done[0]
+	}
+	return w.Initial
+}
+
+func dedupe(list []string) []string {
+	var out []string
+	for _, s := range list {
+		if s != "" && !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
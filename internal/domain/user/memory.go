@@ -0,0 +1,69 @@
+package user
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryRepo keeps users in process memory. It behaves like the Mongo
+// repository and is meant for tests and local runs without a database.
+type memoryRepo struct {
+	mu    sync.Mutex
+	users map[string]User
+}
+
+func NewMemoryRepository() Repository {
+	return &memoryRepo{
+		users: map[string]User{},
+	}
+}
+
+func (r *memoryRepo) FindByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[email]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *memoryRepo) UpsertOTP(ctx context.Context, email, otpHash string, expiresAt time.Time) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	user, ok := r.users[email]
+	if !ok {
+		user = User{
+			ID:        primitive.NewObjectID(),
+			Email:     email,
+			CreatedAt: now,
+		}
+	}
+	user.OTPHash = otpHash
+	user.OTPExpiresAt = expiresAt
+	user.UpdatedAt = now
+
+	r.users[email] = user
+	return &user, nil
+}
+
+func (r *memoryRepo) ClearOTP(ctx context.Context, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for email, user := range r.users {
+		if user.ID == userID {
+			user.OTPHash = ""
+			user.OTPExpiresAt = time.Time{}
+			r.users[email] = user
+			return nil
+		}
+	}
+	return nil
+}
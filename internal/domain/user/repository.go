@@ -2,13 +2,14 @@ package user
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"time"
 
-	"github.com/developwithayush/go-todo-app/internal/db"
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/logger"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -18,22 +19,29 @@ type Repository interface {
 	ClearOTP(ctx context.Context, userID primitive.ObjectID) error
 }
 
-type repo struct{}
+var ErrNotFound = apperr.NotFound("user_not_found", "User not found")
 
-func NewRepository() Repository {
-	return &repo{}
+type repo struct {
+	users *mongo.Collection
+}
+
+func NewRepository(database *mongo.Database) Repository {
+	return &repo{
+		users: database.Collection("users"),
+	}
 }
 
 func (r *repo) FindByEmail(ctx context.Context, email string) (*User, error) {
 	var user User
-	err := db.Users.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := r.users.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// Print user data in JSON format (similar to Express console.log)
-	userJSON, _ := json.MarshalIndent(user, "", "  ")
-	fmt.Println("User:", string(userJSON))
+	logger.FromContext(ctx, logger.Nop()).Debug("user found", logger.Field("user_id", user.ID))
 
 	return &user, nil
 }
@@ -57,7 +65,7 @@ func (r *repo) UpsertOTP(ctx context.Context, email, otpHash string, expiresAt t
 		SetReturnDocument(options.After)
 
 	var user User
-	err := db.Users.FindOneAndUpdate(ctx, bson.M{"email": email}, update, opt).Decode(&user)
+	err := r.users.FindOneAndUpdate(ctx, bson.M{"email": email}, update, opt).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -65,7 +73,7 @@ func (r *repo) UpsertOTP(ctx context.Context, email, otpHash string, expiresAt t
 }
 
 func (r *repo) ClearOTP(ctx context.Context, userID primitive.ObjectID) error {
-	_, err := db.Users.UpdateOne(ctx, userID, bson.M{
+	_, err := r.users.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
 		"$set": bson.M{
 			"otpHash":      "",
 			"otpExpiresAt": time.Time{},
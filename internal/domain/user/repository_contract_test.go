@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/db/dbtest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// repoBackends are the Repository implementations the contract runs
+// against. Backends that need a server skip themselves when it is not
+// configured.
+var repoBackends = []struct {
+	name string
+	new  func(t *testing.T) Repository
+}{
+	{"memory", func(t *testing.T) Repository { return NewMemoryRepository() }},
+	{"mongo", func(t *testing.T) Repository { return NewRepository(dbtest.Mongo(t)) }},
+	{"sqlite", func(t *testing.T) Repository { return NewSQLRepository(dbtest.SQLite(t)) }},
+	{"postgres", func(t *testing.T) Repository { return NewSQLRepository(dbtest.Postgres(t)) }},
+}
+
+var repoContract = []struct {
+	name string
+	run  func(t *testing.T, repo Repository)
+}{
+	{"find a missing user", func(t *testing.T, repo Repository) {
+		if _, err := repo.FindByEmail(context.Background(), "nobody@example.com"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("FindByEmail: got %v, want ErrNotFound", err)
+		}
+	}},
+	{"upsert creates then updates", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		expires := time.Now().Add(5 * time.Minute).Truncate(time.Millisecond)
+
+		created, err := repo.UpsertOTP(ctx, "a@example.com", "hash1", expires)
+		if err != nil {
+			t.Fatalf("UpsertOTP: %v", err)
+		}
+		if created.ID.IsZero() || created.Email != "a@example.com" || created.OTPHash != "hash1" || created.CreatedAt.IsZero() {
+			t.Fatalf("created %+v", created)
+		}
+
+		updated, err := repo.UpsertOTP(ctx, "a@example.com", "hash2", expires.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("UpsertOTP: %v", err)
+		}
+		if updated.ID != created.ID || updated.OTPHash != "hash2" || !updated.OTPExpiresAt.Equal(expires.Add(time.Minute)) {
+			t.Fatalf("updated %+v, want the same user with the new code", updated)
+		}
+
+		found, err := repo.FindByEmail(ctx, "a@example.com")
+		if err != nil {
+			t.Fatalf("FindByEmail: %v", err)
+		}
+		if found.ID != created.ID || found.OTPHash != "hash2" {
+			t.Fatalf("found %+v", found)
+		}
+	}},
+	{"clear otp", func(t *testing.T, repo Repository) {
+		ctx := context.Background()
+		user, err := repo.UpsertOTP(ctx, "b@example.com", "hash", time.Now().Add(time.Minute))
+		if err != nil {
+			t.Fatalf("UpsertOTP: %v", err)
+		}
+		if err := repo.ClearOTP(ctx, user.ID); err != nil {
+			t.Fatalf("ClearOTP: %v", err)
+		}
+		found, err := repo.FindByEmail(ctx, "b@example.com")
+		if err != nil {
+			t.Fatalf("FindByEmail: %v", err)
+		}
+		if found.OTPHash != "" || !found.OTPExpiresAt.IsZero() {
+			t.Fatalf("code left after ClearOTP: %+v", found)
+		}
+		if err := repo.ClearOTP(ctx, primitive.NewObjectID()); err != nil {
+			t.Fatalf("ClearOTP of a missing user: %v", err)
+		}
+	}},
+}
+
+func TestRepositoryContract(t *testing.T) {
+	for _, backend := range repoBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, tc := range repoContract {
+				t.Run(tc.name, func(t *testing.T) {
+					tc.run(t, backend.new(t))
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const userColumns = `id, email, otp_hash, otp_expires_at, created_at, updated_at`
+
+type sqlRepo struct {
+	db *db.SQL
+}
+
+// NewSQLRepository stores users in PostgreSQL or SQLite.
+func NewSQLRepository(database *db.SQL) Repository {
+	return &sqlRepo{db: database}
+}
+
+func (r *sqlRepo) FindByEmail(ctx context.Context, email string) (*User, error) {
+	row := r.db.Conn(ctx).QueryRowContext(ctx,
+		r.db.Rebind(`SELECT `+userColumns+` FROM users WHERE email = ?`),
+		email)
+	user, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *sqlRepo) UpsertOTP(ctx context.Context, email, otpHash string, expiresAt time.Time) (*User, error) {
+	now := time.Now().UTC()
+	row := r.db.Conn(ctx).QueryRowContext(ctx,
+		r.db.Rebind(`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (email) DO UPDATE SET otp_hash = excluded.otp_hash, otp_expires_at = excluded.otp_expires_at, updated_at = excluded.updated_at
+RETURNING `+userColumns),
+		primitive.NewObjectID().Hex(), email, otpHash, expiresAt.UTC(), now, now)
+	user, err := scanUser(row)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *sqlRepo) ClearOTP(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.db.Conn(ctx).ExecContext(ctx,
+		r.db.Rebind(`UPDATE users SET otp_hash = '', otp_expires_at = ? WHERE id = ?`),
+		time.Time{}, userID.Hex())
+	return err
+}
+
+func scanUser(row *sql.Row) (User, error) {
+	var (
+		user User
+		id   string
+	)
+	err := row.Scan(&id, &user.Email, &user.OTPHash, &user.OTPExpiresAt, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return User{}, err
+	}
+	user.ID, err = primitive.ObjectIDFromHex(id)
+	return user, err
+}
@@ -20,3 +20,10 @@ type VerifyOTPResponse struct {
 	Message string `json:"message" example:"OTP verified successfully"`
 	Token   string `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
+
+// CSRFTokenResponse carries the token cookie-authenticated clients send
+// in the X-CSRF-Token header
+// @Description CSRF token for cookie-authenticated requests
+type CSRFTokenResponse struct {
+	Token string `json:"token" example:"q3mZ0f1x2Q5vB8nT4kLw7A.Hq0mV4nS1fWk9eC2rJ6uXy3zP8tLb5aD7gNv0oQ1iE"`
+}
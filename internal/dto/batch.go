@@ -0,0 +1,54 @@
+package dto
+
+// BatchOperation represents a single operation in a batch request
+// @Description One create, update, delete, move or complete operation. Version, when set, must match the todo's current version.
+type BatchOperation struct {
+	Op          string  `json:"op" example:"complete" validate:"required,oneof=create update delete move complete"`
+	ID          string  `json:"id,omitempty" example:"507f1f77bcf86cd799439011" validate:"required_unless=Op create,omitempty,objectid"`
+	Version     int64   `json:"version,omitempty" example:"3" validate:"min=0"`
+	Title       *string `json:"title,omitempty" example:"Buy groceries" validate:"omitnil,max=200"`
+	Description *string `json:"description,omitempty" example:"Milk, eggs, bread" validate:"omitnil,max=2000"`
+	Status      *string `json:"status,omitempty" example:"in_progress" validate:"omitnil,max=50"`
+	Completed   *bool   `json:"completed,omitempty" example:"true"`
+	Position    *int    `json:"position,omitempty" example:"2" validate:"omitnil,min=0"`
+}
+
+// BatchRequest represents the request body for batch operations
+// @Description Operations applied in order
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" validate:"required,min=1,max=100"`
+}
+
+// BatchResultResponse represents the outcome of one batch operation
+// @Description Outcome of one operation: ok, failed, or rolled_back when an atomic batch was aborted
+type BatchResultResponse struct {
+	Index  int           `json:"index" example:"0"`
+	Op     string        `json:"op" example:"complete"`
+	ID     string        `json:"id" example:"507f1f77bcf86cd799439011"`
+	Status string        `json:"status" example:"ok"`
+	Error  string        `json:"error,omitempty" example:"todo not found"`
+	Todo   *TodoResponse `json:"todo,omitempty"`
+}
+
+// BatchResponse represents the response of a batch request
+// @Description Per-operation results in request order
+type BatchResponse struct {
+	Success bool                  `json:"success" example:"true"`
+	Data    []BatchResultResponse `json:"data"`
+}
+
+// BatchProblemResponse represents a rolled back atomic batch
+// @Description Problem details with the outcome of each operation: the one that failed, rolled_back for those before it
+type BatchProblemResponse struct {
+	ErrorResponse
+	Results []BatchResultResponse `json:"results"`
+}
+
+// CountResponse represents the number of todos affected
+// @Description Number of todos affected by a bulk action
+type CountResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Count int `json:"count" example:"12"`
+	} `json:"data"`
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "encoding/json"
+
 // SuccessResponse represents a successful API response
 // @Description Standard success response wrapper
 type SuccessResponse struct {
@@ -8,10 +10,50 @@ type SuccessResponse struct {
 }
 
 // ErrorResponse represents an error API response
-// @Description Standard error response wrapper
+// @Description RFC 7807 problem details, sent as application/problem+json
 type ErrorResponse struct {
-	Success bool   `json:"success" example:"false"`
-	Message string `json:"message" example:"Error message"`
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Not Found"`
+	Status    int          `json:"status" example:"404"`
+	Detail    string       `json:"detail,omitempty" example:"Todo not found"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/todos/507f1f77bcf86cd799439011"`
+	Code      string       `json:"code" example:"todo_not_found"`
+	RequestID string       `json:"requestId,omitempty" example:"3f2b8c0d9e1a4b5c6d7e8f9a0b1c2d3e"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Extensions are sent as extra top-level members. They cannot replace
+	// the members above.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+func (p ErrorResponse) MarshalJSON() ([]byte, error) {
+	type problem ErrorResponse
+	data, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	members := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	for key, value := range p.Extensions {
+		if _, taken := members[key]; taken {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		members[key] = raw
+	}
+	return json.Marshal(members)
+}
+
+// FieldError describes one invalid request field
+// @Description Validation failure for one field
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Code    string `json:"code" example:"required"`
+	Message string `json:"message" example:"Title is required"`
 }
 
 // MessageResponse represents a simple message response
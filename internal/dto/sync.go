@@ -0,0 +1,71 @@
+package dto
+
+import "time"
+
+// SyncMutation represents a single change recorded by an offline client
+// @Description A create, update or delete made while offline. BaseSeq is the seq of the todo the client last saw.
+type SyncMutation struct {
+	Op          string  `json:"op" example:"update" validate:"required,oneof=create update delete"`
+	ID          string  `json:"id" example:"507f1f77bcf86cd799439011" validate:"required_unless=Op create,omitempty,objectid"`
+	BaseSeq     int64   `json:"baseSeq" example:"12" validate:"min=0"`
+	Title       *string `json:"title,omitempty" example:"Buy groceries" validate:"omitnil,max=200"`
+	Description *string `json:"description,omitempty" example:"Milk, eggs, bread" validate:"omitnil,max=2000"`
+	Status      *string `json:"status,omitempty" example:"done" validate:"omitnil,max=50"`
+	Completed   *bool   `json:"completed,omitempty" example:"true"`
+}
+
+// SyncPushRequest represents the request body for applying offline changes
+// @Description Batch of client mutations applied in order. Since is the token of the client's last pull.
+type SyncPushRequest struct {
+	Since     string         `json:"since,omitempty" example:"42"`
+	Mutations []SyncMutation `json:"mutations" validate:"max=500"`
+}
+
+// TombstoneResponse represents a deleted todo
+// @Description Marker for a todo deleted after the sync token
+type TombstoneResponse struct {
+	ID        string    `json:"id" example:"507f1f77bcf86cd799439011"`
+	Seq       int64     `json:"seq" example:"13"`
+	DeletedAt time.Time `json:"deletedAt" example:"2024-01-15T10:30:00Z"`
+}
+
+// SyncChangesResponse represents the changes since a sync token
+// @Description Todos changed and deleted since the given token
+type SyncChangesResponse struct {
+	Token   string              `json:"token" example:"42"`
+	Todos   []TodoResponse      `json:"todos"`
+	Deleted []TombstoneResponse `json:"deleted"`
+	HasMore bool                `json:"hasMore" example:"false"`
+}
+
+// SyncPullResponse represents the response of a delta sync
+// @Description Response containing changes since the given token
+type SyncPullResponse struct {
+	Success bool                `json:"success" example:"true"`
+	Data    SyncChangesResponse `json:"data"`
+}
+
+// SyncResultResponse represents the outcome of a single mutation
+// @Description Outcome of one client mutation: applied, conflict or rejected
+type SyncResultResponse struct {
+	Index  int           `json:"index" example:"0"`
+	Op     string        `json:"op" example:"update"`
+	ID     string        `json:"id" example:"507f1f77bcf86cd799439011"`
+	Status string        `json:"status" example:"conflict"`
+	Error  string        `json:"error,omitempty" example:"todo changed on the server"`
+	Todo   *TodoResponse `json:"todo,omitempty"`
+}
+
+// SyncPushResult represents the outcome of a mutation batch
+// @Description Per-mutation results and the token to pull from next. Pulling from it returns the applied mutations again, together with anything other devices changed meanwhile.
+type SyncPushResult struct {
+	Token   string               `json:"token" example:"42"`
+	Results []SyncResultResponse `json:"results"`
+}
+
+// SyncPushResponse represents the response after applying mutations
+// @Description Response after applying a batch of client mutations
+type SyncPushResponse struct {
+	Success bool           `json:"success" example:"true"`
+	Data    SyncPushResult `json:"data"`
+}
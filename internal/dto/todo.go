@@ -5,28 +5,80 @@ import "time"
 // CreateTodoRequest represents the request body for creating a todo
 // @Description Request body for creating a new todo item
 type CreateTodoRequest struct {
-	Title       string `json:"title" example:"Buy groceries" validate:"required"`
-	Description string `json:"description" example:"Milk, eggs, bread"`
+	Title       string `json:"title" example:"Buy groceries" validate:"required,max=200"`
+	Description string `json:"description" example:"Milk, eggs, bread" validate:"max=2000"`
+	Status      string `json:"status" example:"todo" validate:"max=50"`
+	Color       string `json:"color" example:"#1e90ff" validate:"omitempty,hexcolor"`
+	Recurrence  string `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO,WE" validate:"omitempty,rrule"`
+	Timezone    string `json:"timezone" example:"Europe/Berlin" validate:"omitempty,timezone"`
 }
 
 // UpdateTodoRequest represents the request body for updating a todo
-// @Description Request body for updating an existing todo item
+// @Description Request body for updating an existing todo item. An empty description, color, recurrence or timezone clears it; omitted optional fields are left unchanged.
 type UpdateTodoRequest struct {
-	Title       string `json:"title" example:"Buy groceries (updated)"`
-	Description string `json:"description" example:"Milk, eggs, bread, butter"`
+	Title       string  `json:"title" example:"Buy groceries (updated)" validate:"required,max=200"`
+	Description *string `json:"description" example:"Milk, eggs, bread, butter" validate:"omitnil,max=2000"`
+	Status      *string `json:"status" example:"in_progress" validate:"omitnil,max=50"`
+	Color       *string `json:"color" example:"#1e90ff" validate:"omitzero,hexcolor"`
+	Recurrence  *string `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO,WE" validate:"omitzero,rrule"`
+	Timezone    *string `json:"timezone" example:"Europe/Berlin" validate:"omitzero,timezone"`
+	Completed   *bool   `json:"completed" example:"true"`
+}
+
+// PatchTodoRequest represents a JSON Merge Patch for a todo
+// @Description Merge patch for a todo. Only the fields present are changed; null clears a field.
+type PatchTodoRequest struct {
+	Title       string `json:"title" example:"Buy groceries (updated)" validate:"max=200"`
+	Description string `json:"description" example:"Milk, eggs, bread, butter" validate:"max=2000"`
+	Status      string `json:"status" example:"in_progress" validate:"max=50"`
+	Color       string `json:"color" example:"#1e90ff" validate:"omitempty,hexcolor"`
+	Recurrence  string `json:"recurrence" example:"FREQ=WEEKLY;BYDAY=MO,WE" validate:"omitempty,rrule"`
+	Timezone    string `json:"timezone" example:"Europe/Berlin" validate:"omitempty,timezone"`
+	Completed   bool   `json:"completed" example:"true"`
+	Position    int    `json:"position" example:"2" validate:"min=0"`
 }
 
 // TodoResponse represents a single todo item in the response
 // @Description Todo item response structure
 type TodoResponse struct {
-	ID          string    `json:"id" example:"507f1f77bcf86cd799439011"`
-	UserID      string    `json:"userId" example:"507f1f77bcf86cd799439012"`
-	Title       string    `json:"title" example:"Buy groceries"`
-	Description string    `json:"description" example:"Milk, eggs, bread"`
-	Completed   bool      `json:"completed" example:"false"`
-	Position    int       `json:"position" example:"0"`
-	CreatedAt   time.Time `json:"createdAt" example:"2024-01-15T10:30:00Z"`
-	UpdatedAt   time.Time `json:"updatedAt" example:"2024-01-15T10:30:00Z"`
+	ID          string                 `json:"id" example:"507f1f77bcf86cd799439011"`
+	UserID      string                 `json:"userId" example:"507f1f77bcf86cd799439012"`
+	Title       string                 `json:"title" example:"Buy groceries"`
+	Description string                 `json:"description" example:"Milk, eggs, bread"`
+	Status      string                 `json:"status" example:"in_progress"`
+	Color       string                 `json:"color,omitempty" example:"#1e90ff"`
+	Recurrence  string                 `json:"recurrence,omitempty" example:"FREQ=WEEKLY;BYDAY=MO,WE"`
+	Timezone    string                 `json:"timezone,omitempty" example:"Europe/Berlin"`
+	Completed   bool                   `json:"completed" example:"false"`
+	CompletedAt *time.Time             `json:"completedAt" example:"2024-01-16T09:00:00Z"`
+	History     []StatusChangeResponse `json:"history,omitempty"`
+	Position    int                    `json:"position" example:"0"`
+	Version     int64                  `json:"version" example:"3"`
+	Seq         int64                  `json:"seq" example:"42"`
+	CreatedAt   time.Time              `json:"createdAt" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt   time.Time              `json:"updatedAt" example:"2024-01-15T10:30:00Z"`
+}
+
+// StatusChangeResponse represents one status transition of a todo
+// @Description Entry in a todo's status history
+type StatusChangeResponse struct {
+	From string    `json:"from" example:"todo"`
+	To   string    `json:"to" example:"in_progress"`
+	At   time.Time `json:"at" example:"2024-01-15T11:00:00Z"`
+}
+
+// BoardColumnResponse represents one column of the board view
+// @Description Todos in one status, ordered by position
+type BoardColumnResponse struct {
+	Status string         `json:"status" example:"in_progress"`
+	Todos  []TodoResponse `json:"todos"`
+}
+
+// BoardResponse represents todos grouped by status
+// @Description Response containing one column per workflow status
+type BoardResponse struct {
+	Success bool                  `json:"success" example:"true"`
+	Data    []BoardColumnResponse `json:"data"`
 }
 
 // TodoListResponse represents the response containing list of todos
@@ -0,0 +1,81 @@
+package dto
+
+import "time"
+
+// ExportQuery represents the query of an export request
+// @Description Export options
+type ExportQuery struct {
+	Format string `query:"format" json:"format" validate:"omitempty,oneof=json csv todotxt markdown"`
+}
+
+// ImportQuery represents the query of an import request
+// @Description Import options
+type ImportQuery struct {
+	Format string `query:"format" json:"format" validate:"omitempty,oneof=json csv todotxt markdown"`
+	DryRun bool   `query:"dryRun" json:"dryRun"`
+	Dedupe string `query:"dedupe" json:"dedupe" validate:"omitempty,oneof=none title id"`
+	Async  bool   `query:"async" json:"async"`
+}
+
+// TodoRecord represents a todo in a JSON export or import file
+// @Description Todo as exported and imported. Only title is required on import; status wins over completed when both are set.
+type TodoRecord struct {
+	ID          string     `json:"id,omitempty" example:"507f1f77bcf86cd799439011"`
+	Title       string     `json:"title" example:"Buy groceries" validate:"required,max=200"`
+	Description string     `json:"description,omitempty" example:"Milk, eggs, bread" validate:"max=2000"`
+	Status      string     `json:"status,omitempty" example:"done" validate:"max=50"`
+	Completed   bool       `json:"completed" example:"true"`
+	CompletedAt *time.Time `json:"completedAt,omitempty" example:"2024-01-16T09:00:00Z"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty" example:"2024-01-15T10:30:00Z"`
+}
+
+// ImportRowResponse represents the outcome of one imported row
+// @Description Outcome of one row: created, valid (dry run), skipped as a duplicate, or failed
+type ImportRowResponse struct {
+	Row    int    `json:"row" example:"3"`
+	Status string `json:"status" example:"failed"`
+	Title  string `json:"title,omitempty" example:"Buy groceries"`
+	ID     string `json:"id,omitempty" example:"507f1f77bcf86cd799439011"`
+	Error  string `json:"error,omitempty" example:"title is required"`
+}
+
+// ImportReportResponse represents the result of an import
+// @Description Row counts and per-row outcomes of an import
+type ImportReportResponse struct {
+	Format  string              `json:"format" example:"csv"`
+	DryRun  bool                `json:"dryRun" example:"false"`
+	Total   int                 `json:"total" example:"120"`
+	Created int                 `json:"created" example:"117"`
+	Skipped int                 `json:"skipped" example:"2"`
+	Failed  int                 `json:"failed" example:"1"`
+	Rows    []ImportRowResponse `json:"rows"`
+}
+
+// ImportResponse represents the response of an import run in the request
+// @Description Import report
+type ImportResponse struct {
+	Success bool                 `json:"success" example:"true"`
+	Data    ImportReportResponse `json:"data"`
+}
+
+// JobResponseData represents the state of a background job
+// @Description Background job. Result holds the import report once status is succeeded.
+type JobResponseData struct {
+	ID         string                `json:"id" example:"65a1b2c3d4e5f6a7b8c9d0e1"`
+	Kind       string                `json:"kind" example:"todo_import"`
+	Status     string                `json:"status" example:"running" enums:"running,succeeded,failed"`
+	Done       int                   `json:"done" example:"1500"`
+	Total      int                   `json:"total" example:"4000"`
+	Result     *ImportReportResponse `json:"result,omitempty"`
+	Error      string                `json:"error,omitempty" example:"Job failed"`
+	CreatedAt  time.Time             `json:"createdAt" example:"2024-01-15T10:30:00Z"`
+	UpdatedAt  time.Time             `json:"updatedAt" example:"2024-01-15T10:30:05Z"`
+	FinishedAt *time.Time            `json:"finishedAt,omitempty" example:"2024-01-15T10:31:00Z"`
+}
+
+// JobResponse represents a background job in the response
+// @Description Response containing a background job
+type JobResponse struct {
+	Success bool            `json:"success" example:"true"`
+	Data    JobResponseData `json:"data"`
+}
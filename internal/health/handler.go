@@ -0,0 +1,34 @@
+package health
+
+import "github.com/gofiber/fiber/v3"
+
+// Liveness reports that the process is up and serving requests. It checks
+// no dependencies, so a database outage does not get the pod restarted.
+func (c *Checker) Liveness(ctx fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{"status": "ok"})
+}
+
+// Readiness reports whether the service should receive traffic. It
+// answers 503 when a critical check fails or the server is shutting down.
+// The body only has the overall status, so it is safe on the public port;
+// failing checks and their errors are logged.
+func (c *Checker) Readiness(ctx fiber.Ctx) error {
+	report := c.Report(ctx.Context())
+	return readiness(ctx, report.Status, fiber.Map{"status": report.Status})
+}
+
+// ReadinessDetails is Readiness with the result of every dependency check,
+// including error messages, for the admin port.
+func (c *Checker) ReadinessDetails(ctx fiber.Ctx) error {
+	report := c.Report(ctx.Context())
+	return readiness(ctx, report.Status, report)
+}
+
+func readiness(ctx fiber.Ctx, status string, body interface{}) error {
+	code := fiber.StatusOK
+	if status == StatusNotReady || status == StatusShuttingDown {
+		code = fiber.StatusServiceUnavailable
+	}
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.Status(code).JSON(body)
+}
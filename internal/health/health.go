@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/logger"
+)
+
+const (
+	defaultTimeout = 2 * time.Second
+	defaultTTL     = 5 * time.Second
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	StatusReady        = "ready"
+	StatusDegraded     = "degraded"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check is one dependency probed by the readiness endpoint.
+type Check struct {
+	Name string
+	// Critical checks make the service not ready when they fail; the others
+	// only mark it degraded, for dependencies the app can run without.
+	Critical bool
+	// Timeout bounds a single run, 2s by default.
+	Timeout time.Duration
+	// TTL is how long a result is reused before the check runs again, 5s
+	// by default, so frequent probes do not hammer the dependency.
+	TTL time.Duration
+	Run func(ctx context.Context) error
+}
+
+// Result is the outcome of the latest run of a check.
+type Result struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Report is the readiness of the service and the result of every check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type entry struct {
+	Check
+	mu   sync.Mutex
+	last *Result
+}
+
+// Checker runs the registered checks and caches their results.
+type Checker struct {
+	logr         logger.Logger
+	mu           sync.RWMutex
+	checks       []*entry
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(logr logger.Logger) *Checker {
+	return &Checker{logr: logr}
+}
+
+func (c *Checker) Add(check Check) {
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+	if check.TTL <= 0 {
+		check.TTL = defaultTTL
+	}
+	c.mu.Lock()
+	c.checks = append(c.checks, &entry{Check: check})
+	c.mu.Unlock()
+}
+
+// SetShuttingDown makes the service report not ready from now on, so load
+// balancers stop sending traffic while it drains.
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Report runs the checks whose cached result expired, concurrently, and
+// returns the combined readiness.
+func (c *Checker) Report(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown, Checks: map[string]Result{}}
+	}
+
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, e := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.result(ctx, e)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: make(map[string]Result, len(checks))}
+	for i, e := range checks {
+		r := results[i]
+		report.Checks[e.Name] = r
+		if r.Status == StatusUp {
+			continue
+		}
+		if r.Critical {
+			report.Status = StatusNotReady
+		} else if report.Status == StatusReady {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// result returns the cached result of a check, running it when the cache
+// expired. Concurrent probes wait for the same run. The result is shared
+// with them and cached, so the run is bounded by the check's timeout only,
+// not by the probe that happened to start it.
+func (c *Checker) result(ctx context.Context, e *entry) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.last != nil && time.Since(e.last.CheckedAt) < e.TTL {
+		return *e.last
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := e.Run(ctx)
+	r := Result{
+		Status:    StatusUp,
+		Critical:  e.Critical,
+		Duration:  time.Since(start).String(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		r.Status, r.Error = StatusDown, err.Error()
+	}
+
+	if e.last == nil || e.last.Status != r.Status {
+		if err != nil {
+			c.logr.Warn("health check failed", logger.Field("check", e.Name), logger.Field("error", err))
+		} else if e.last != nil {
+			c.logr.Info("health check recovered", logger.Field("check", e.Name))
+		}
+	}
+	e.last = &r
+	return r
+}
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const dbError = "dial tcp 10.0.3.7:5432: connect: connection refused"
+
+func probe(t *testing.T, handler fiber.Handler) (int, map[string]interface{}) {
+	t.Helper()
+	app := fiber.New()
+	app.Get("/readyz", handler)
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/readyz", nil))
+	if err != nil {
+		t.Fatalf("GET /readyz: %v", err)
+	}
+	defer resp.Body.Close()
+	var body map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.StatusCode, body
+}
+
+func failingChecker(logr logger.Logger) *Checker {
+	checks := NewChecker(logr)
+	checks.Add(Check{Name: "postgres", Critical: true, Run: func(ctx context.Context) error {
+		return errors.New(dbError)
+	}})
+	return checks
+}
+
+func TestReadinessHidesDetails(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	checks := failingChecker(zap.New(core))
+
+	code, body := probe(t, checks.Readiness)
+	if code != fiber.StatusServiceUnavailable || body["status"] != StatusNotReady {
+		t.Fatalf("got %d %v, want 503 not_ready", code, body)
+	}
+	if len(body) != 1 {
+		t.Fatalf("public body = %v, want only the status", body)
+	}
+
+	entries := logs.FilterMessage("health check failed").All()
+	if len(entries) != 1 || !strings.Contains(entries[0].ContextMap()["error"].(string), dbError) {
+		t.Fatalf("failed check not logged with its error: %v", logs.All())
+	}
+}
+
+func TestReadinessDetails(t *testing.T) {
+	checks := failingChecker(logger.Nop())
+
+	code, body := probe(t, checks.ReadinessDetails)
+	if code != fiber.StatusServiceUnavailable {
+		t.Fatalf("got %d, want 503", code)
+	}
+	result, _ := body["checks"].(map[string]interface{})["postgres"].(map[string]interface{})
+	if result["status"] != StatusDown || result["error"] != dbError {
+		t.Fatalf("details = %v, want postgres down with its error", body)
+	}
+}
+
+func TestCheckOutlivesCancelledProbe(t *testing.T) {
+	checks := NewChecker(logger.Nop())
+	runs := 0
+	checks.Add(Check{Name: "db", Critical: true, Run: func(ctx context.Context) error {
+		runs++
+		return ctx.Err()
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := checks.Report(ctx); report.Status != StatusReady {
+		t.Fatalf("report for a cancelled probe = %+v, want ready", report)
+	}
+	if report := checks.Report(context.Background()); report.Status != StatusReady || runs != 1 {
+		t.Fatalf("next probe = %+v after %d runs, want the cached ready result", report, runs)
+	}
+}
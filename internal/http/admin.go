@@ -0,0 +1,19 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/developwithayush/go-todo-app/internal/health"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
+)
+
+// NewAdminApp builds the app served on the admin port: metrics and the
+// health probes, away from the public API. Its /readyz includes the
+// result of every check.
+func NewAdminApp(checks *health.Checker) *fiber.App {
+	app := fiber.New(fiber.Config{AppName: "Go Todo App admin", ErrorHandler: ErrorHandler})
+	app.Get("/metrics", metrics.Handler())
+	app.Get("/healthz", checks.Liveness)
+	app.Get("/readyz", checks.ReadinessDetails)
+	return app
+}
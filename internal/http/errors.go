@@ -0,0 +1,59 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/dto"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// ErrorHandler renders every error a handler returns as RFC 7807 problem
+// details. Typed errors keep their status, code, field details and
+// extension members, Fiber errors keep their status, and anything else is
+// a 500 whose cause is left to the request log. Errors marked as
+// rendered already have their response.
+func ErrorHandler(c fiber.Ctx, err error) error {
+	if apperr.IsRendered(err) {
+		return nil
+	}
+	status := apperr.Status(err)
+	problem := dto.ErrorResponse{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   http.StatusText(status),
+		Instance: c.Path(),
+		Code:     "internal",
+	}
+	if id, ok := c.Locals("requestID").(string); ok {
+		problem.RequestID = id
+	}
+
+	var ae *apperr.Error
+	var fe *fiber.Error
+	switch {
+	case errors.As(err, &ae):
+		problem.Code, problem.Detail = ae.Code, ae.Message
+		problem.Extensions = ae.Extensions
+		for _, f := range ae.Fields {
+			problem.Errors = append(problem.Errors, dto.FieldError{Field: f.Field, Code: f.Code, Message: f.Message})
+		}
+	case errors.As(err, &fe):
+		problem.Code, problem.Detail = statusCode(status), fe.Message
+	}
+
+	c.Status(status)
+	return c.JSON(problem, mimeProblemJSON)
+}
+
+// statusCode turns a status text such as "Method Not Allowed" into a code
+// such as method_not_allowed.
+func statusCode(status int) string {
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
@@ -1,41 +1,53 @@
 package middleware
 
 import (
-	"fmt"
 	"time"
 
+	"github.com/developwithayush/go-todo-app/internal/apperr"
 	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/developwithayush/go-todo-app/internal/logger"
 	"github.com/gofiber/fiber/v3"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var (
+	errUnauthenticated = apperr.Unauthorized("unauthenticated", "Authentication required")
+	errInvalidToken    = apperr.Unauthorized("invalid_token", "Invalid token")
+	errTokenExpired    = apperr.Unauthorized("token_expired", "Token expired")
+)
+
+// AuthRequired accepts the JWT in the session cookie.
 func AuthRequired(cfg *config.Config) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		tokenStr := c.Cookies(cfg.CookieName)
 		if tokenStr == "" {
-			return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+			return errUnauthenticated
 		}
 
 		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
 			return []byte(cfg.JWTSecret), nil
 		})
 		if err != nil || !token.Valid {
-			return c.Status(401).JSON(fiber.Map{"error": "invalid token"})
+			return errInvalidToken
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
-		fmt.Println(claims)
 		if !ok {
-			return c.Status(401).JSON(fiber.Map{"error": "invalid claims"})
+			return errInvalidToken
 		}
+		logger.FromContext(c.Context(), logger.Nop()).Debug("token verified",
+			logger.Field("sub", claims["sub"]),
+			logger.Field("exp", claims["exp"]),
+		)
 
 		if exp, ok := claims["exp"].(float64); ok {
 			if time.Now().Unix() > int64(exp) {
-				return c.Status(401).JSON(fiber.Map{"error": "token expired"})
+				return errTokenExpired
 			}
 		}
 
 		c.Locals("userID", claims["sub"])
+		c.SetContext(logger.With(c.Context(), logger.Field("user_id", claims["sub"])))
 
 		return c.Next()
 	}
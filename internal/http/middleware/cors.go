@@ -1,17 +1,101 @@
 package middleware
 
-import "github.com/gofiber/fiber/v3"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+// corsExposeHeaders are the response headers the API sets that browser
+// clients may read.
+const corsExposeHeaders = "ETag, X-Request-ID, Idempotent-Replayed, RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After"
+
+// CORS allows the configured origins to call the API from a browser. The
+// request's Origin is echoed back when it matches, and responses vary on
+// Origin so caches keep them apart. Preflight requests are answered here;
+// other requests from an origin that does not match get no CORS headers,
+// so the browser blocks them.
+func CORS(cfg *config.Config) fiber.Handler {
+	match := originMatcher(cfg.CORSOrigins)
+	methods := strings.Join(cfg.CORSMethods, ", ")
+	headers := strings.Join(cfg.CORSHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.CORSMaxAge.Seconds()))
 
-func CORS() fiber.Handler {
 	return func(c fiber.Ctx) error {
-		c.Set("Access-Control-Allow-Origin", "http://localhost:3000") // adjust
-		c.Set("Access-Control-Allow-Credentials", "true")
-		c.Set("Access-Control-Allow-Methods", "GET,POST,PUT,DELETE,OPTIONS")
-		c.Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Vary(fiber.HeaderOrigin)
+		origin := c.Get(fiber.HeaderOrigin)
+		preflight := c.Method() == fiber.MethodOptions && c.Get(fiber.HeaderAccessControlRequestMethod) != ""
+
+		if origin == "" || !match(origin) {
+			if preflight {
+				return c.SendStatus(fiber.StatusNoContent)
+			}
+			return c.Next()
+		}
 
-		if c.Method() == fiber.MethodOptions {
-			return c.SendStatus(204)
+		c.Set(fiber.HeaderAccessControlAllowOrigin, origin)
+		if cfg.CORSCredentials {
+			c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
 		}
+
+		if preflight {
+			c.Vary(fiber.HeaderAccessControlRequestMethod, fiber.HeaderAccessControlRequestHeaders)
+			c.Set(fiber.HeaderAccessControlAllowMethods, methods)
+			if headers != "" {
+				c.Set(fiber.HeaderAccessControlAllowHeaders, headers)
+			}
+			if cfg.CORSMaxAge > 0 {
+				c.Set(fiber.HeaderAccessControlMaxAge, maxAge)
+			}
+			return c.SendStatus(fiber.StatusNoContent)
+		}
+
+		c.Set(fiber.HeaderAccessControlExposeHeaders, corsExposeHeaders)
 		return c.Next()
 	}
 }
+
+// originMatcher returns a func reporting whether an Origin header matches
+// one of the allowed origins: *, an exact origin, or a wildcard such as
+// https://*.example.com for any subdomain (but not example.com itself).
+func originMatcher(allowed []string) func(string) bool {
+	exact := map[string]bool{}
+	var anyOrigin bool
+	var suffixes [][2]string // scheme://, .host[:port]
+	for _, o := range allowed {
+		o = strings.ToLower(o)
+		switch {
+		case o == "*":
+			anyOrigin = true
+		case strings.Contains(o, "://*."):
+			scheme, host, _ := strings.Cut(o, "*.")
+			suffixes = append(suffixes, [2]string{scheme, "." + host})
+		default:
+			exact[o] = true
+		}
+	}
+
+	return func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		origin = strings.ToLower(origin)
+		if exact[origin] {
+			return true
+		}
+		for _, s := range suffixes {
+			rest, ok := strings.CutPrefix(origin, s[0])
+			if !ok || !strings.HasSuffix(rest, s[1]) {
+				continue
+			}
+			// the subdomain must be a host name, not a path or port trick
+			sub := strings.TrimSuffix(rest, s[1])
+			if sub != "" && !strings.ContainsAny(sub, "/:@?#") {
+				return true
+			}
+		}
+		return false
+	}
+}
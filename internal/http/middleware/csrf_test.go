@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/developwithayush/go-todo-app/internal/util"
+	"github.com/gofiber/fiber/v3"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// csrfApp serves /todos behind AuthRequired and CSRF.
+func csrfApp(t *testing.T) (*fiber.App, *config.Config, string) {
+	t.Helper()
+	cfg := config.Default()
+	session, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "507f1f77bcf86cd799439011",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c fiber.Ctx, err error) error {
+			return c.Status(apperr.Status(err)).SendString(err.Error())
+		},
+	})
+	app.All("/todos", AuthRequired(cfg), CSRF(cfg), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	return app, cfg, session
+}
+
+func TestCSRF(t *testing.T) {
+	app, cfg, session := csrfApp(t)
+	token, err := util.NewCSRFToken(cfg.JWTSecret, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherToken, err := util.NewCSRFToken(cfg.JWTSecret, session+"x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		header map[string]string
+		want   int
+	}{
+		{"read without token", fiber.MethodGet, nil, fiber.StatusNoContent},
+		{"write with token", fiber.MethodPost, map[string]string{CSRFHeader: token}, fiber.StatusNoContent},
+		{"write with token and own origin", fiber.MethodDelete, map[string]string{CSRFHeader: token, fiber.HeaderOrigin: "http://example.com"}, fiber.StatusNoContent},
+		{"write with token and CORS origin", fiber.MethodPatch, map[string]string{CSRFHeader: token, fiber.HeaderOrigin: "http://localhost:3000"}, fiber.StatusNoContent},
+		{"write without token", fiber.MethodPost, nil, fiber.StatusForbidden},
+		{"write with another session's token", fiber.MethodPut, map[string]string{CSRFHeader: otherToken}, fiber.StatusForbidden},
+		{"write from a foreign origin", fiber.MethodPost, map[string]string{CSRFHeader: token, fiber.HeaderOrigin: "https://evil.example"}, fiber.StatusForbidden},
+		{"write from a foreign referer", fiber.MethodPost, map[string]string{CSRFHeader: token, fiber.HeaderReferer: "https://evil.example/page"}, fiber.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/todos", nil)
+			req.AddCookie(&http.Cookie{Name: cfg.CookieName, Value: session})
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthRequiresSessionCookie(t *testing.T) {
+	app, _, session := csrfApp(t)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/todos", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+session)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401 for a token outside the session cookie", resp.StatusCode)
+	}
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/cache"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	idempotencyHeader     = "Idempotency-Key"
+	idempotencyMaxKeyLen  = 255
+	idempotencyPendingTTL = time.Minute
+	idempotencyTTL        = 24 * time.Hour
+	// idempotencyAttempts bounds how often the key is taken again after
+	// the request holding it released it between our two store calls
+	idempotencyAttempts = 3
+)
+
+// idempotencyRefresh is how often the pending marker is renewed while the
+// first request runs. Tests shorten it.
+var idempotencyRefresh = idempotencyPendingTTL / 3
+
+var (
+	errIdempotencyKeyTooLong  = apperr.Validation("idempotency_key_too_long", "Idempotency-Key is too long")
+	errIdempotencyInProgress  = apperr.Conflict("idempotency_in_progress", "A request with this Idempotency-Key is in progress")
+	errIdempotencyKeyMismatch = apperr.New(apperr.KindUnprocessable, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+)
+
+// replayedHeaders are the response headers stored with a response and sent
+// again when it is replayed. Set-Cookie is never stored: a cookie may carry
+// a session, and the store is no place for credentials.
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderETag, fiber.HeaderLocation}
+
+// idempotencyRecord is what is stored under a key: a pending marker while
+// the first request runs, then the response it produced.
+type idempotencyRecord struct {
+	Pending     bool              `json:"pending"`
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// Idempotency makes POST, PATCH and DELETE requests that carry an
+// Idempotency-Key header safe to retry. The first response for a key is
+// stored in the cache store, scoped to the user (or client IP before
+// login), and replayed for later requests with the same key. A retry that
+// arrives while the first request is still running gets 409, and reusing a
+// key for a different request gets 422. The first request's pending marker
+// is renewed for as long as it runs. Server errors are not stored so the
+// client can retry them; client errors are stored and still returned, so
+// logging and metrics see them. When the store is unavailable requests run
+// normally.
+//
+// Cookies set by the first response are not replayed, so do not use it on
+// routes that log in.
+func Idempotency(store cache.Store, log logger.Logger) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodPost, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return c.Next()
+		}
+		key := c.Get(idempotencyHeader)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > idempotencyMaxKeyLen {
+			return errIdempotencyKeyTooLong
+		}
+
+		scope, ok := c.Locals("userID").(string)
+		if !ok {
+			scope = c.IP()
+		}
+		storeKey := "idem:" + scope + ":" + key
+		fingerprint := requestFingerprint(c)
+
+		ctx, cancel := context.WithTimeout(c.Context(), 2*time.Second)
+		defer cancel()
+
+		pending, _ := json.Marshal(idempotencyRecord{Pending: true, Fingerprint: fingerprint})
+		for attempt := 1; ; attempt++ {
+			acquired, err := store.SetNX(ctx, storeKey, pending, idempotencyPendingTTL)
+			if err != nil {
+				logger.FromContext(c.Context(), log).Warn("idempotency store unavailable", logger.Field("error", err))
+				return c.Next()
+			}
+			if acquired {
+				break
+			}
+
+			record, err := loadRecord(ctx, store, storeKey)
+			if errors.Is(err, cache.ErrMiss) {
+				// the first request failed and released the key after our
+				// SetNX; take it ourselves
+				if attempt < idempotencyAttempts {
+					continue
+				}
+				return errIdempotencyInProgress
+			}
+			if err != nil {
+				logger.FromContext(c.Context(), log).Warn("idempotency store unavailable", logger.Field("error", err))
+				return c.Next()
+			}
+			if record.Fingerprint != fingerprint {
+				return errIdempotencyKeyMismatch
+			}
+			if record.Pending {
+				return errIdempotencyInProgress
+			}
+			return replay(c, record)
+		}
+
+		stopRefresh := refreshPending(store, storeKey, logger.FromContext(c.Context(), log))
+		// a panicking handler must not leave its key renewed forever
+		defer stopRefresh()
+		err := c.Next()
+		stopRefresh()
+		// the handler may outlive the request timeout; use a fresh context
+		storeCtx, storeCancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer storeCancel()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = apperr.Status(err)
+		}
+		if status >= fiber.StatusInternalServerError {
+			if delErr := store.Delete(storeCtx, storeKey); delErr != nil {
+				logger.FromContext(c.Context(), log).Warn("failed to release idempotency key", logger.Field("error", delErr))
+			}
+			return err
+		}
+		if err != nil {
+			// render client errors now so the problem body can be stored
+			if renderErr := c.App().ErrorHandler(c, err); renderErr != nil {
+				return renderErr
+			}
+		}
+
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			Status:      status,
+			Headers:     map[string]string{},
+			Body:        append([]byte(nil), c.Response().Body()...),
+		}
+		for _, h := range replayedHeaders {
+			if v := c.GetRespHeader(h); v != "" {
+				record.Headers[h] = v
+			}
+		}
+		data, _ := json.Marshal(record)
+		if setErr := store.Set(storeCtx, storeKey, data, idempotencyTTL); setErr != nil {
+			logger.FromContext(c.Context(), log).Warn("failed to store idempotent response", logger.Field("error", setErr))
+		}
+		if err != nil {
+			// logging and metrics still see the client error
+			return apperr.Rendered(err)
+		}
+		return nil
+	}
+}
+
+// refreshPending renews the pending marker at key every
+// idempotencyRefresh until the returned stop is called, so a request that runs for longer than
+// idempotencyPendingTTL keeps its key. When this replica dies the marker
+// still expires. stop returns once no renewal is in flight; calls after
+// the first do nothing.
+func refreshPending(store cache.Store, key string, log logger.Logger) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				if err := store.Expire(ctx, key, idempotencyPendingTTL); err != nil {
+					log.Warn("failed to renew idempotency key", logger.Field("error", err))
+				}
+				cancel()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
+
+// requestFingerprint identifies the request a key was first used for.
+func requestFingerprint(c fiber.Ctx) string {
+	h := sha256.New()
+	h.Write([]byte(c.Method()))
+	h.Write([]byte{0})
+	h.Write([]byte(c.OriginalURL()))
+	h.Write([]byte{0})
+	h.Write(c.Body())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func loadRecord(ctx context.Context, store cache.Store, key string) (idempotencyRecord, error) {
+	var record idempotencyRecord
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return record, err
+	}
+	err = json.Unmarshal(data, &record)
+	return record, err
+}
+
+func replay(c fiber.Ctx, record idempotencyRecord) error {
+	for k, v := range record.Headers {
+		c.Set(k, v)
+	}
+	c.Set("Idempotent-Replayed", "true")
+	return c.Status(record.Status).Send(record.Body)
+}
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/cache"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/gofiber/fiber/v3"
+)
+
+const sessionCookie = "session=eyJhbGciOiJIUzI1NiJ9.e30.signature; Path=/; HttpOnly"
+
+// idempotentApp serves POST /items behind Idempotency. Every call of the
+// handler is counted.
+func idempotentApp(store cache.Store) (*fiber.App, *int) {
+	calls := 0
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c fiber.Ctx, err error) error {
+			return c.Status(apperr.Status(err)).SendString(err.Error())
+		},
+	})
+	app.Post("/items", Idempotency(store, logger.Nop()), func(c fiber.Ctx) error {
+		calls++
+		c.Response().Header.Add(fiber.HeaderSetCookie, sessionCookie)
+		return c.Status(fiber.StatusCreated).SendString("created " + string(c.Body()))
+	})
+	return app, &calls
+}
+
+func post(t *testing.T, app *fiber.App, key, body string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodPost, "/items", strings.NewReader(body))
+	req.Header.Set(idempotencyHeader, key)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("POST /items: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestIdempotencyReplaysWithoutCookies(t *testing.T) {
+	store := cache.NewMemoryStore(100)
+	app, calls := idempotentApp(store)
+
+	first := post(t, app, "k1", "a")
+	if first.StatusCode != fiber.StatusCreated || first.Header.Get(fiber.HeaderSetCookie) == "" {
+		t.Fatalf("first response: %d, Set-Cookie %q", first.StatusCode, first.Header.Get(fiber.HeaderSetCookie))
+	}
+
+	second := post(t, app, "k1", "a")
+	if *calls != 1 {
+		t.Fatalf("handler ran %d times, want once", *calls)
+	}
+	if second.StatusCode != fiber.StatusCreated || readBody(t, second) != "created a" || second.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay: %d %q", second.StatusCode, second.Header.Get("Idempotent-Replayed"))
+	}
+	if cookie := second.Header.Get(fiber.HeaderSetCookie); cookie != "" {
+		t.Fatalf("replay set cookie %q", cookie)
+	}
+
+	stored, err := store.Get(context.Background(), "idem:0.0.0.0:k1")
+	if err != nil {
+		t.Fatalf("stored record: %v", err)
+	}
+	if strings.Contains(string(stored), "session=") {
+		t.Fatalf("stored record holds the cookie: %s", stored)
+	}
+}
+
+func TestIdempotencyKeyReuse(t *testing.T) {
+	app, calls := idempotentApp(cache.NewMemoryStore(100))
+	post(t, app, "k1", "a")
+
+	if resp := post(t, app, "k1", "b"); resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Fatalf("reused key: %d, want 422", resp.StatusCode)
+	}
+	if *calls != 1 {
+		t.Fatalf("handler ran %d times, want once", *calls)
+	}
+}
+
+// releasingStore is a store where the request holding a key releases it
+// between a retry's SetNX and Get, as when the first request fails with a
+// server error.
+type releasingStore struct {
+	cache.Store
+	mu       sync.Mutex
+	releases int
+}
+
+func (s *releasingStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.releases > 0 {
+		s.releases--
+		return false, nil
+	}
+	return s.Store.SetNX(ctx, key, value, ttl)
+}
+
+func TestIdempotencyRetriesReleasedKey(t *testing.T) {
+	store := &releasingStore{Store: cache.NewMemoryStore(100), releases: 1}
+	app, calls := idempotentApp(store)
+
+	resp := post(t, app, "k1", "a")
+	if resp.StatusCode != fiber.StatusCreated || *calls != 1 {
+		t.Fatalf("got %d after %d calls, want the request to take the released key", resp.StatusCode, *calls)
+	}
+}
+
+func TestIdempotencyGivesUpOnChurningKey(t *testing.T) {
+	store := &releasingStore{Store: cache.NewMemoryStore(100), releases: idempotencyAttempts}
+	app, calls := idempotentApp(store)
+
+	if resp := post(t, app, "k1", "a"); resp.StatusCode != fiber.StatusConflict || *calls != 0 {
+		t.Fatalf("got %d after %d calls, want 409 without running", resp.StatusCode, *calls)
+	}
+}
+
+func TestIdempotencyReturnsStoredClientErrors(t *testing.T) {
+	store := cache.NewMemoryStore(100)
+	renders := 0
+	var seen error
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c fiber.Ctx, err error) error {
+			if apperr.IsRendered(err) {
+				return nil
+			}
+			renders++
+			return c.Status(apperr.Status(err)).SendString(err.Error())
+		},
+	})
+	errInvalid := apperr.Validation("invalid_item", "Invalid item")
+	app.Post("/items", func(c fiber.Ctx) error {
+		// stands in for the logging and metrics middleware
+		seen = c.Next()
+		return seen
+	}, Idempotency(store, logger.Nop()), func(c fiber.Ctx) error {
+		return errInvalid
+	})
+
+	first := post(t, app, "k1", "a")
+	if first.StatusCode != fiber.StatusBadRequest || readBody(t, first) != "Invalid item" {
+		t.Fatalf("first response: %d", first.StatusCode)
+	}
+	if !errors.Is(seen, errInvalid) || apperr.Status(seen) != fiber.StatusBadRequest {
+		t.Fatalf("middleware before Idempotency saw %v, want the client error", seen)
+	}
+	if renders != 1 {
+		t.Fatalf("error rendered %d times, want once", renders)
+	}
+	if ttl, err := store.TTL(context.Background(), "idem:0.0.0.0:k1"); err != nil || ttl < time.Hour {
+		t.Fatalf("stored response TTL = %v, %v; want the full idempotency TTL", ttl, err)
+	}
+
+	second := post(t, app, "k1", "a")
+	if second.StatusCode != fiber.StatusBadRequest || readBody(t, second) != "Invalid item" || second.Header.Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay: %d %q", second.StatusCode, second.Header.Get("Idempotent-Replayed"))
+	}
+}
+
+func TestRefreshPendingRenewsUntilStopped(t *testing.T) {
+	defer func(d time.Duration) { idempotencyRefresh = d }(idempotencyRefresh)
+	idempotencyRefresh = 10 * time.Millisecond
+
+	store := cache.NewMemoryStore(100)
+	ctx := context.Background()
+	store.Set(ctx, "idem:k", []byte("pending"), 30*time.Millisecond)
+
+	stop := refreshPending(store, "idem:k", logger.Nop())
+	time.Sleep(60 * time.Millisecond)
+	if _, err := store.Get(ctx, "idem:k"); err != nil {
+		t.Fatalf("pending marker of a running request expired: %v", err)
+	}
+
+	stop()
+	stop()
+	store.Set(ctx, "idem:k", []byte("response"), idempotencyTTL)
+	time.Sleep(30 * time.Millisecond)
+	if ttl, _ := store.TTL(ctx, "idem:k"); ttl < time.Hour {
+		t.Fatalf("stored response TTL = %v after stop, want it left alone", ttl)
+	}
+}
+
+// expiringStore counts the renewals of keys.
+type expiringStore struct {
+	cache.Store
+	expires atomic.Int32
+}
+
+func (s *expiringStore) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	s.expires.Add(1)
+	return s.Store.Expire(ctx, key, ttl)
+}
+
+func TestIdempotencyRefreshesWhileRunning(t *testing.T) {
+	defer func(d time.Duration) { idempotencyRefresh = d }(idempotencyRefresh)
+	idempotencyRefresh = 5 * time.Millisecond
+
+	store := &expiringStore{Store: cache.NewMemoryStore(100)}
+	app := fiber.New()
+	app.Post("/items", func(c fiber.Ctx) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fiber.ErrInternalServerError
+			}
+		}()
+		return c.Next()
+	}, Idempotency(store, logger.Nop()), func(c fiber.Ctx) error {
+		time.Sleep(30 * time.Millisecond)
+		if string(c.Body()) == "panic" {
+			panic("boom")
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	for _, body := range []string{"ok", "panic"} {
+		before := store.expires.Load()
+		post(t, app, "k-"+body, body)
+		after := store.expires.Load()
+		if after == before {
+			t.Fatalf("%s: pending marker was not renewed while the handler ran", body)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if n := store.expires.Load(); n != after {
+			t.Fatalf("%s: %d renewals after the request finished, want none", body, n-after)
+		}
+	}
+}
@@ -3,23 +3,51 @@ package middleware
 import (
 	"time"
 
-	"github.com/gofiber/fiber/v3"
+	"github.com/developwithayush/go-todo-app/internal/apperr"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/gofiber/fiber/v3"
+	"go.uber.org/zap"
 )
 
+// Logging puts a request-scoped logger in the request context, with the
+// request ID, trace IDs and route, and logs one line per request when it
+// completes. AuthRequired adds the user ID to it. Handlers and
+// repositories reach it through logger.FromContext.
 func Logging(log logger.Logger) fiber.Handler {
 	return func(c fiber.Ctx) error {
 		start := time.Now()
+
+		reqLog := logger.WithTrace(c.Context(), log).With(logger.Field("request_id", c.Locals("requestID")))
+		// read when a line is written, so it names the route running then
+		reqLog = logger.WithLazy(reqLog, "route", c.FullPath)
+		c.SetContext(logger.NewContext(c.Context(), reqLog))
+
 		err := c.Next()
 		duration := time.Since(start)
 
-		log.Info("request",
+		status := c.Response().StatusCode()
+		if err != nil {
+			// the app error handler writes the response after us
+			status = apperr.Status(err)
+		}
+
+		fields := []zap.Field{
 			logger.Field("method", c.Method()),
 			logger.Field("path", c.Path()),
-			logger.Field("status", c.Response().StatusCode()),
+			logger.Field("status", status),
 			logger.Field("duration", duration.String()),
-		)
+			logger.Field("ip", c.IP()),
+		}
+		if err != nil {
+			fields = append(fields, logger.Field("error", err.Error()))
+		}
 
+		l := logger.FromContext(c.Context(), reqLog)
+		if status >= fiber.StatusInternalServerError {
+			l.Error("request", fields...)
+		} else {
+			l.Info("request", fields...)
+		}
 		return err
 	}
 }
@@ -1,16 +1,19 @@
 package middleware
 
 import (
-	"github.com/gofiber/fiber/v3"
+	"fmt"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/gofiber/fiber/v3"
 )
 
 func Recover(log logger.Logger) fiber.Handler {
 	return func(c fiber.Ctx) (err error) {
 		defer func() {
 			if r := recover(); r != nil {
-				log.Error("panic recovered", logger.Field("panic", r))
-				_ = c.Status(500).JSON(fiber.Map{"error": "internal server error"})
+				logger.FromContext(c.Context(), log).Error("panic recovered", logger.Field("panic", r))
+				err = apperr.Internal("Internal Server Error", fmt.Errorf("panic: %v", r))
 			}
 		}()
 		return c.Next()
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// longest request ID accepted from a client
+	maxRequestIDLen = 128
+)
+
+// RequestID gives every request an ID, taken from the X-Request-ID header
+// when the client or a proxy sent a usable one, and generated otherwise.
+// The ID is echoed in the response header and stored in c.Locals as
+// "requestID" for logs and error bodies.
+func RequestID() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Locals("requestID", id)
+		c.Set(RequestIDHeader, id)
+		return c.Next()
+	}
+}
+
+// validRequestID accepts IDs made of letters, digits and -_.: so a client
+// cannot inject anything into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
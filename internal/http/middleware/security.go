@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// securityProfile is a set of security headers for one kind of
+// deployment. Empty values are not sent.
+type securityProfile struct {
+	HSTS           string
+	FrameOptions   string
+	ReferrerPolicy string
+	// CSP applies to API responses. SwaggerCSP applies under /swagger,
+	// whose UI needs its own scripts, inline styles and data: images.
+	CSP        string
+	SwaggerCSP string
+}
+
+const (
+	apiCSP     = "default-src 'none'; frame-ancestors 'none'"
+	swaggerCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+)
+
+// securityProfiles are selected with config.Config.SecurityProfile.
+// relaxed leaves out HSTS, which would pin localhost and plain-HTTP test
+// hosts to HTTPS in the browser.
+var securityProfiles = map[string]securityProfile{
+	"strict": {
+		HSTS:           "max-age=63072000; includeSubDomains",
+		FrameOptions:   "DENY",
+		ReferrerPolicy: "no-referrer",
+		CSP:            apiCSP,
+		SwaggerCSP:     swaggerCSP,
+	},
+	"relaxed": {
+		FrameOptions:   "SAMEORIGIN",
+		ReferrerPolicy: "strict-origin-when-cross-origin",
+		CSP:            apiCSP,
+		SwaggerCSP:     swaggerCSP,
+	},
+}
+
+// SecurityHeaders sets the headers of the named profile on every response.
+// The off profile, or any unknown name, sets none.
+func SecurityHeaders(profile string) fiber.Handler {
+	p, ok := securityProfiles[profile]
+	if !ok {
+		return func(c fiber.Ctx) error { return c.Next() }
+	}
+
+	return func(c fiber.Ctx) error {
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		if p.HSTS != "" {
+			c.Set(fiber.HeaderStrictTransportSecurity, p.HSTS)
+		}
+		if p.FrameOptions != "" {
+			c.Set(fiber.HeaderXFrameOptions, p.FrameOptions)
+		}
+		if p.ReferrerPolicy != "" {
+			c.Set(fiber.HeaderReferrerPolicy, p.ReferrerPolicy)
+		}
+		csp := p.CSP
+		if strings.HasPrefix(c.Path(), "/swagger/") {
+			csp = p.SwaggerCSP
+		}
+		if csp != "" {
+			c.Set(fiber.HeaderContentSecurityPolicy, csp)
+		}
+		return c.Next()
+	}
+}
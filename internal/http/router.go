@@ -1,54 +1,110 @@
 package http
 
 import (
+	"time"
+
 	"github.com/gofiber/fiber/v3"
 
+	"github.com/developwithayush/go-todo-app/internal/cache"
 	"github.com/developwithayush/go-todo-app/internal/config"
 	"github.com/developwithayush/go-todo-app/internal/domain/auth"
 	"github.com/developwithayush/go-todo-app/internal/domain/todo"
 	"github.com/developwithayush/go-todo-app/internal/domain/user"
+	"github.com/developwithayush/go-todo-app/internal/health"
 	"github.com/developwithayush/go-todo-app/internal/http/middleware"
+	"github.com/developwithayush/go-todo-app/internal/jobs"
 	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
+	"github.com/developwithayush/go-todo-app/internal/realtime"
+	"github.com/developwithayush/go-todo-app/internal/telemetry"
 	"github.com/developwithayush/go-todo-app/internal/util"
 )
 
-func RegisterRoutes(app *fiber.App, cfg *config.Config, log logger.Logger) {
+func RegisterRoutes(app *fiber.App, cfg *config.Config, log logger.Logger, checks *health.Checker, hub *realtime.Hub, runner *jobs.Runner, store cache.Store, userRepo user.Repository, todoRepo todo.Repository) {
 	// global middleware
+	app.Use(middleware.RequestID())
+	app.Use(metrics.Middleware())
+	app.Use(telemetry.Middleware())
 	app.Use(middleware.Recover(log))
 	app.Use(middleware.Logging(log))
-	app.Use(middleware.CORS())
+	app.Use(middleware.SecurityHeaders(cfg.SecurityProfile()))
+	app.Use(middleware.CORS(cfg))
 
 	// health check
 	app.Get("/", func(c fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
+	app.Get("/healthz", checks.Liveness)
+	app.Get("/readyz", checks.Readiness)
+
+	// Prometheus metrics, unless they are served on the admin port
+	if cfg.AdminPort == 0 {
+		app.Get("/metrics", metrics.Handler())
+	}
 
 	// Swagger documentation
 	RegisterSwagger(app)
 
 	// deps
-	userRepo := user.NewRepository()
 	_ = user.NewService(userRepo) // reserved for future extra logic
 
 	mailer, _ := util.NewMailer(cfg)
+	// OTP login needs mail, but the rest of the API works without it
+	checks.Add(health.Check{Name: "mail", Timeout: 3 * time.Second, TTL: 30 * time.Second, Run: mailer.Ping})
 
 	authSvc := auth.NewService(cfg, userRepo, mailer)
 	authHandler := auth.NewHandler(authSvc, cfg, log)
 
-	todoRepo := todo.NewRepository()
-	todoHandler := todo.NewHandler(todoRepo, log)
+	workflow := todo.NewWorkflow(cfg.TodoStatuses, cfg.TodoInitialStatus, cfg.TodoDoneStatuses)
+	todoHandler := todo.NewHandler(todoRepo, hub, workflow, runner, log)
 
 	api := app.Group("/api/v1")
 
+	// retries with the same Idempotency-Key replay the first response
+	idem := middleware.Idempotency(store, log)
+
+	// rate limits fall back to per-node counting while Redis is down
+	limitStore := cache.NewFallbackStore(store, cache.NewMemoryStore(cfg.CacheSize))
+	limit := func(group string) fiber.Handler {
+		rule, ok := cfg.RateLimits[group]
+		if !ok {
+			group, rule = middleware.DefaultRateLimitGroup, cfg.RateLimits[middleware.DefaultRateLimitGroup]
+		}
+		return middleware.RateLimit(limitStore, group, rule, log)
+	}
+
 	// Auth routes
-	api.Post("/auth/send-otp", authHandler.SendOTP)
-	api.Post("/auth/verify-otp", authHandler.VerifyOTP)
+	authLimit := limit("auth")
+	api.Post("/auth/send-otp", authLimit, idem, authHandler.SendOTP)
+	// not idem: the response sets the session cookie, which is not stored
+	api.Post("/auth/verify-otp", authLimit, authHandler.VerifyOTP)
 
-	// Todo routes (protected)
 	authMW := middleware.AuthRequired(cfg)
-	todoGroup := api.Group("/todos", authMW)
+	// authenticated mutations must send a token from /auth/csrf
+	csrf := middleware.CSRF(cfg)
+	api.Get("/auth/csrf", authMW, authLimit, authHandler.CSRFToken)
+
+	// Todo routes (protected)
+	todoGroup := api.Group("/todos", authMW, csrf, limit("todos"), idem)
 	todoGroup.Get("/", todoHandler.ListTodos)
 	todoGroup.Post("/", todoHandler.CreateTodo)
+	todoGroup.Get("/board", todoHandler.GetBoard)
+	todoGroup.Get("/export", todoHandler.ExportTodos)
+	todoGroup.Post("/import", todoHandler.ImportTodos)
+	todoGroup.Get("/import/jobs/:id", todoHandler.GetImportJob)
+	todoGroup.Post("/batch", todoHandler.BatchTodos)
+	todoGroup.Post("/complete-all", todoHandler.CompleteAll)
+	todoGroup.Delete("/completed", todoHandler.DeleteCompleted)
+	todoGroup.Get("/:id", todoHandler.GetTodo)
 	todoGroup.Put("/:id", todoHandler.UpdateTodo)
+	todoGroup.Patch("/:id", todoHandler.PatchTodo)
 	todoGroup.Delete("/:id", todoHandler.DeleteTodo)
+
+	// Delta sync for offline clients (protected)
+	syncLimit := limit("sync")
+	api.Get("/sync", authMW, syncLimit, todoHandler.PullChanges)
+	api.Post("/sync", authMW, csrf, syncLimit, idem, todoHandler.PushChanges)
+
+	// Realtime updates (protected)
+	api.Get("/stream", authMW, limit("stream"), todoHandler.StreamTodos)
 }
@@ -0,0 +1,213 @@
+// Package jobs runs long requests in the background. Job state is kept in
+// the cache store, so any replica can answer a status poll, while the work
+// itself runs on the replica that accepted it.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/cache"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	// jobTTL is how long a job can be polled after its last update.
+	jobTTL = 24 * time.Hour
+	// progressInterval limits how often progress is written to the store.
+	progressInterval = time.Second
+	keyPrefix        = "job:"
+)
+
+var ErrNotFound = apperr.NotFound("job_not_found", "Job not found")
+
+// Job is the state of one background job as clients see it.
+type Job struct {
+	ID         string          `json:"id"`
+	Kind       string          `json:"kind"`
+	Status     string          `json:"status"`
+	Done       int             `json:"done"`
+	Total      int             `json:"total"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+}
+
+// Func is the work of a job. It calls progress as it goes and returns the
+// result clients read once the job succeeded.
+type Func func(ctx context.Context, progress func(done, total int)) (any, error)
+
+// Runner starts jobs and tracks them until Shutdown.
+type Runner struct {
+	store cache.Store
+	logr  logger.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRunner keeps job records in store. An in-process store pins them,
+// so cached lists and rate limits cannot push a job out of its LRU
+// before the job has finished and been polled.
+func NewRunner(store cache.Store, logr logger.Logger) *Runner {
+	if m, ok := store.(*cache.MemoryStore); ok {
+		m.Pin(keyPrefix)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Runner{store: store, logr: logr, ctx: ctx, cancel: cancel}
+}
+
+// Start records a running job owned by owner and runs fn in the
+// background. fn gets a context of its own, cancelled by Shutdown: the
+// request's context ends with the response, and its logger reads the
+// request. Log lines of the job carry job_id, which the start is logged
+// with.
+func (r *Runner) Start(ctx context.Context, owner, kind string, total int, fn Func) (*Job, error) {
+	now := time.Now()
+	job := &Job{
+		ID:        primitive.NewObjectID().Hex(),
+		Kind:      kind,
+		Status:    StatusRunning,
+		Total:     total,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := r.save(ctx, owner, job); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx, r.logr).Info("job started", logger.Field("job_id", job.ID), logger.Field("kind", kind))
+	jobCtx, cancel := context.WithCancel(r.ctx)
+	jobCtx = logger.NewContext(jobCtx, r.logr.With(logger.Field("job_id", job.ID)))
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer cancel()
+		r.run(jobCtx, owner, *job, fn)
+	}()
+	return job, nil
+}
+
+func (r *Runner) run(ctx context.Context, owner string, job Job, fn Func) {
+	log := logger.FromContext(ctx, r.logr)
+	var saved time.Time
+	progress := func(done, total int) {
+		job.Done, job.Total = done, total
+		if time.Since(saved) < progressInterval {
+			return
+		}
+		saved = time.Now()
+		job.UpdatedAt = saved
+		if err := r.save(ctx, owner, &job); err != nil {
+			log.Warn("failed to save job progress", logger.Field("error", err))
+		}
+	}
+
+	result, err := r.call(ctx, fn, progress)
+
+	now := time.Now()
+	job.UpdatedAt, job.FinishedAt = now, &now
+	if err == nil {
+		job.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		log.Error("job failed", logger.Field("kind", job.Kind), logger.Field("error", err))
+		job.Status = StatusFailed
+		job.Error = publicError(err)
+	} else {
+		job.Status = StatusSucceeded
+		log.Info("job finished", logger.Field("kind", job.Kind), logger.Field("duration", now.Sub(job.CreatedAt)))
+	}
+
+	// the job's context may be cancelled by now, but its outcome must land
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if err := r.save(saveCtx, owner, &job); err != nil {
+		log.Error("failed to save job result", logger.Field("error", err))
+	}
+}
+
+// call runs fn, turning a panic into an error so one bad job cannot take
+// the process down.
+func (r *Runner) call(ctx context.Context, fn Func, progress func(done, total int)) (result any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = apperr.Internal("Job failed", errors.New("panic in job"))
+			logger.FromContext(ctx, r.logr).Error("job panicked", logger.Field("panic", p))
+		}
+	}()
+	return fn(ctx, progress)
+}
+
+// Get returns a job owned by owner, or ErrNotFound.
+func (r *Runner) Get(ctx context.Context, owner, id string) (*Job, error) {
+	data, err := r.store.Get(ctx, key(owner, id))
+	if errors.Is(err, cache.ErrMiss) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Shutdown cancels running jobs and waits for them to record their
+// outcome, or for ctx to end.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.cancel()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) save(ctx context.Context, owner string, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return r.store.Set(ctx, key(owner, job.ID), data, jobTTL)
+}
+
+// publicError is the message clients see for a failed job. Typed errors
+// are meant for clients; anything else may be a storage error.
+func publicError(err error) string {
+	var e *apperr.Error
+	if errors.As(err, &e) && e.Kind != apperr.KindInternal {
+		return e.Message
+	}
+	if errors.Is(err, context.Canceled) {
+		return "Job was interrupted by a server shutdown"
+	}
+	return "Job failed"
+}
+
+func key(owner, id string) string {
+	return keyPrefix + owner + ":" + id
+}
@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/cache"
+	"github.com/developwithayush/go-todo-app/internal/logger"
+)
+
+// countingStore counts the writes a runner makes.
+type countingStore struct {
+	cache.Store
+	sets atomic.Int32
+}
+
+func (s *countingStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.sets.Add(1)
+	return s.Store.Set(ctx, key, value, ttl)
+}
+
+// wait polls the job until it is no longer running.
+func wait(t *testing.T, r *Runner, owner, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := r.Get(context.Background(), owner, id)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if job.Status != StatusRunning {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is still running", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestStartAndGet(t *testing.T) {
+	r := NewRunner(cache.NewMemoryStore(10), logger.Nop())
+	ctx := context.Background()
+	release := make(chan struct{})
+
+	job, err := r.Start(ctx, "u1", "import", 2, func(ctx context.Context, progress func(done, total int)) (any, error) {
+		<-release
+		progress(2, 2)
+		return map[string]int{"imported": 2}, nil
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if job.Status != StatusRunning || job.Kind != "import" || job.Total != 2 {
+		t.Fatalf("started job = %+v", job)
+	}
+
+	running, err := r.Get(ctx, "u1", job.ID)
+	if err != nil || running.Status != StatusRunning {
+		t.Fatalf("Get while running = %+v, %v", running, err)
+	}
+	if _, err := r.Get(ctx, "u2", job.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get as another owner: got %v, want ErrNotFound", err)
+	}
+
+	close(release)
+	done := wait(t, r, "u1", job.ID)
+	if done.Status != StatusSucceeded || done.Done != 2 || done.FinishedAt == nil {
+		t.Fatalf("finished job = %+v", done)
+	}
+	if string(done.Result) != `{"imported":2}` {
+		t.Fatalf("result = %s", done.Result)
+	}
+}
+
+func TestProgressIsThrottled(t *testing.T) {
+	store := &countingStore{Store: cache.NewMemoryStore(10)}
+	r := NewRunner(store, logger.Nop())
+	reported := make(chan struct{})
+	release := make(chan struct{})
+
+	job, err := r.Start(context.Background(), "u1", "import", 100, func(ctx context.Context, progress func(done, total int)) (any, error) {
+		for i := 1; i <= 100; i++ {
+			progress(i, 100)
+		}
+		close(reported)
+		<-release
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	<-reported
+	// the start and the first progress call, not one write per call
+	if n := store.sets.Load(); n != 2 {
+		t.Fatalf("%d writes during a burst of progress, want 2", n)
+	}
+	running, _ := r.Get(context.Background(), "u1", job.ID)
+	if running.Done != 1 {
+		t.Fatalf("stored progress = %d, want the first call's", running.Done)
+	}
+
+	close(release)
+	if done := wait(t, r, "u1", job.ID); done.Done != 100 {
+		t.Fatalf("final progress = %d, want 100", done.Done)
+	}
+}
+
+func TestPanicFailsWithGenericMessage(t *testing.T) {
+	r := NewRunner(cache.NewMemoryStore(10), logger.Nop())
+	job, err := r.Start(context.Background(), "u1", "import", 1, func(ctx context.Context, progress func(done, total int)) (any, error) {
+		panic("dial mongodb://admin:hunter2@db: refused")
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	done := wait(t, r, "u1", job.ID)
+	if done.Status != StatusFailed || done.Error != "Job failed" {
+		t.Fatalf("panicked job = %+v, want failed with a generic message", done)
+	}
+	if strings.Contains(done.Error, "hunter2") {
+		t.Fatalf("job error leaks the panic: %q", done.Error)
+	}
+}
+
+func TestShutdownCancelsAndWaits(t *testing.T) {
+	r := NewRunner(cache.NewMemoryStore(10), logger.Nop())
+	started := make(chan struct{})
+	job, err := r.Start(context.Background(), "u1", "import", 1, func(ctx context.Context, progress func(done, total int)) (any, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	// Shutdown returned after the job recorded its outcome
+	got, err := r.Get(context.Background(), "u1", job.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != StatusFailed || got.Error != "Job was interrupted by a server shutdown" {
+		t.Fatalf("interrupted job = %+v", got)
+	}
+}
+
+func TestShutdownGivesUpWithItsContext(t *testing.T) {
+	r := NewRunner(cache.NewMemoryStore(10), logger.Nop())
+	release := make(chan struct{})
+	defer close(release)
+	if _, err := r.Start(context.Background(), "u1", "import", 1, func(ctx context.Context, progress func(done, total int)) (any, error) {
+		// ignores cancellation
+		<-release
+		return nil, nil
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := r.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown: got %v, want the context's error", err)
+	}
+}
+
+func TestJobsOutliveTheMemoryStoreLRU(t *testing.T) {
+	store := cache.NewMemoryStore(2)
+	r := NewRunner(store, logger.Nop())
+	ctx := context.Background()
+	job, err := r.Start(ctx, "u1", "import", 0, func(ctx context.Context, progress func(done, total int)) (any, error) {
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	wait(t, r, "u1", job.ID)
+
+	// other features fill the store well past its capacity
+	for _, key := range []string{"list:a", "list:b", "rate:c", "rate:d", "idem:e"} {
+		store.Set(ctx, key, []byte("x"), time.Minute)
+	}
+	if got, err := r.Get(ctx, "u1", job.ID); err != nil || got.Status != StatusSucceeded {
+		t.Fatalf("Get after the LRU filled up = %+v, %v; want the finished job", got, err)
+	}
+}
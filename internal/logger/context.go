@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying l. Handlers and repositories
+// log through FromContext so their lines keep the request's fields.
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or fallback when there
+// is none, such as in background work.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return l
+	}
+	return fallback
+}
+
+// With adds fields to the logger carried by ctx. ctx is returned as is
+// when it carries no logger.
+func With(ctx context.Context, fields ...zap.Field) context.Context {
+	l, ok := ctx.Value(ctxKey{}).(Logger)
+	if !ok {
+		return ctx
+	}
+	return NewContext(ctx, l.With(fields...))
+}
+
+// WithLazy adds a field whose value is computed each time a line is
+// written, for values that change while the logger is in use. Fields added
+// with With are encoded immediately.
+func WithLazy(l Logger, key string, value func() string) Logger {
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return lazyCore{Core: core, key: key, value: value}
+	}))
+}
+
+type lazyCore struct {
+	zapcore.Core
+	key   string
+	value func() string
+}
+
+func (c lazyCore) With(fields []zapcore.Field) zapcore.Core {
+	return lazyCore{Core: c.Core.With(fields), key: c.key, value: c.value}
+}
+
+func (c lazyCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c lazyCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, append(fields, zap.String(c.key, c.value())))
+}
@@ -3,29 +3,40 @@ package logger
 import (
 	"github.com/developwithayush/go-todo-app/internal/config"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
-
 type Logger = *zap.Logger
 
+// NewLogger builds the app logger: JSON in production and console output
+// otherwise, at cfg.LogLevel or the environment's default level. Every
+// line passes through the redaction rules in redact.go.
 func NewLogger(cfg *config.Config) Logger {
-	var log *zap.Logger
-	var err error
-
-	if cfg.Env ==  "prod" || cfg.Env == "production" {
-		log, err = zap.NewProduction()
-	} else {
-		log, err = zap.NewDevelopment()
+	zcfg := zap.NewDevelopmentConfig()
+	if cfg.IsProduction() {
+		zcfg = zap.NewProductionConfig()
+	}
+	if cfg.LogLevel != "" {
+		level, err := zapcore.ParseLevel(cfg.LogLevel)
+		if err != nil {
+			panic(err)
+		}
+		zcfg.Level = zap.NewAtomicLevelAt(level)
 	}
 
+	log, err := zcfg.Build(zap.WrapCore(newRedactCore))
 	if err != nil {
 		panic(err)
 	}
 
 	return log
 }
- 
 
+// Nop returns a logger that discards everything, for code paths that may
+// run without a request logger in their context.
+func Nop() Logger {
+	return zap.NewNop()
+}
 
 func Field(key string, value interface{}) zap.Field {
 	return zap.Any(key, value)
@@ -0,0 +1,21 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTrace returns l with the trace and span IDs of the span in ctx, so
+// log lines can be matched with traces. l is returned as is when ctx holds
+// no span.
+func WithTrace(ctx context.Context, l Logger) Logger {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return l
+	}
+	return l.With(
+		Field("trace_id", sc.TraceID().String()),
+		Field("span_id", sc.SpanID().String()),
+	)
+}
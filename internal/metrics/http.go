@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Middleware records every request in HTTPRequests and HTTPDuration.
+// Register it before the routes so it sees all of them.
+func Middleware() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		HTTPInFlight.Inc()
+		defer HTTPInFlight.Dec()
+
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// the app error handler writes the response after us
+			status = apperr.Status(err)
+		}
+		labels := []string{c.Method(), Route(c, status), strconv.Itoa(status)}
+		HTTPRequests.WithLabelValues(labels...).Inc()
+		HTTPDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// Route returns the route template that answered the request. Requests
+// stopped by group middleware, such as auth, use the group's path, and
+// requests no route matched use "unmatched".
+func Route(c fiber.Ctx, status int) string {
+	switch {
+	case c.Matched():
+		return c.FullPath()
+	case status == fiber.StatusNotFound || status == fiber.StatusMethodNotAllowed:
+		return "unmatched"
+	}
+	return c.FullPath()
+}
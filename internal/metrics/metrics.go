@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "todo_app"
+
+// HTTPRequests and HTTPDuration are labeled by route template, such as
+// /api/v1/todos/:id, so the number of series does not grow with the ids in
+// request paths. Requests no route matched use "unmatched".
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+)
+
+// MongoDuration times MongoDB commands by command name, collection and
+// result: ok or error.
+var MongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "mongo_command_duration_seconds",
+	Help:      "MongoDB command latency by command, collection and result.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"command", "collection", "result"})
+
+// CacheRequests counts cache lookups by cache name and result: hit, miss
+// or error. Errors are lookups that fell back to the database because the
+// cache was unavailable.
+var CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "cache_requests_total",
+	Help:      "Cache lookups by cache and result.",
+}, []string{"cache", "result"})
+
+// OTPEvents counts OTP sends and verifications by result. Sends are ok or
+// error; verifications are ok, not_found, expired, invalid or error.
+var OTPEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "otp_events_total",
+	Help:      "OTP sends and verifications by action and result.",
+}, []string{"action", "result"})
+
+// MailQueueDepth is the number of emails waiting or being sent. Mail is
+// sent inline with the request, so this is the number of sends in flight.
+var (
+	MailQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "mail_queue_depth",
+		Help:      "Emails waiting or being sent.",
+	})
+
+	MailSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mail_sent_total",
+		Help:      "Emails sent by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	// the default Go collector only exports the classic memstats; add the
+	// GC and scheduler runtime metrics
+	prometheus.Unregister(collectors.NewGoCollector())
+	prometheus.MustRegister(collectors.NewGoCollector(
+		collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsGC, collectors.MetricsScheduler),
+	))
+}
+
+// RegisterDB exports the connection pool stats of a SQL database.
+func RegisterDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the registered metrics in the Prometheus text format.
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.Handler())
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MongoMonitor returns a command monitor that records MongoDuration. Set it
+// on the client options.
+func MongoMonitor() *event.CommandMonitor {
+	// the collection is only known when the command starts
+	var collections sync.Map
+
+	finished := func(requestID int64, command, result string, seconds float64) {
+		collection, _ := collections.LoadAndDelete(requestID)
+		name, _ := collection.(string)
+		MongoDuration.WithLabelValues(command, name, result).Observe(seconds)
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			name, ok := e.Command.Lookup(e.CommandName).StringValueOK()
+			if !ok {
+				// getMore names the collection in its own field
+				name, _ = e.Command.Lookup("collection").StringValueOK()
+			}
+			collections.Store(e.RequestID, name)
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.RequestID, e.CommandName, "ok", e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finished(e.RequestID, e.CommandName, "error", e.Duration.Seconds())
+		},
+	}
+}
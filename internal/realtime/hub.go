@@ -0,0 +1,326 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoDeleted   = "todo.deleted"
+	EventTodoReordered = "todo.reordered"
+)
+
+const (
+	// channel every replica subscribes to for live fan-out
+	channel = "todo:events"
+	// per-user stream kept for Last-Event-ID replay
+	streamPrefix = "todo:events:"
+	streamMaxLen = 500
+	streamTTL    = 24 * time.Hour
+	bufferSize   = 64
+	// how often local mode drops the history of users idle for streamTTL
+	localSweepInterval = time.Minute
+)
+
+// Event is a single change notification delivered to a user's clients.
+type Event struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	UserID string          `json:"userId"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Hub publishes todo events through Redis and fans them out to the
+// subscribers connected to this replica. Without a Redis client it runs
+// in local mode for single-node deployments: events go straight to local
+// subscribers and the replay history is kept in memory, with the same
+// per-user length and expiry as the Redis streams.
+type Hub struct {
+	client *redis.Client
+	logr   logger.Logger
+
+	mu     sync.RWMutex
+	subs   map[string]map[chan Event]struct{}
+	closed bool
+
+	localMu    sync.Mutex
+	local      map[string][]Event
+	localMs    uint64
+	localSeq   uint64
+	localSwept time.Time
+}
+
+func NewHub(client *redis.Client, logr logger.Logger) *Hub {
+	return &Hub{
+		client: client,
+		logr:   logr,
+		subs:   make(map[string]map[chan Event]struct{}),
+		local:  make(map[string][]Event),
+	}
+}
+
+// Run listens on the Redis channel and dispatches events to local
+// subscribers until ctx is cancelled.
+func (h *Hub) Run(ctx context.Context) {
+	if h.client == nil {
+		<-ctx.Done()
+		h.closeAll()
+		return
+	}
+
+	pubsub := h.client.Subscribe(ctx, channel)
+	defer pubsub.Close()
+
+	msgs := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			h.closeAll()
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				h.closeAll()
+				return
+			}
+			var ev Event
+			if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+				h.logr.Warn("invalid realtime event", logger.Field("error", err))
+				continue
+			}
+			h.dispatch(ev)
+		}
+	}
+}
+
+// Publish appends the event to the user's replay stream and announces it
+// to every replica.
+func (h *Hub) Publish(ctx context.Context, userID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if h.client == nil {
+		h.dispatch(h.record(Event{Type: eventType, UserID: userID, Data: payload}))
+		return nil
+	}
+
+	key := streamPrefix + userID
+	id, err := h.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"type": eventType, "data": string(payload)},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	h.client.Expire(ctx, key, streamTTL)
+
+	msg, err := json.Marshal(Event{ID: id, Type: eventType, UserID: userID, Data: payload})
+	if err != nil {
+		return err
+	}
+	return h.client.Publish(ctx, channel, msg).Err()
+}
+
+// Replay returns the events recorded for the user after lastID, which
+// must be a valid ID.
+func (h *Hub) Replay(ctx context.Context, userID, lastID string) ([]Event, error) {
+	if h.client == nil {
+		h.localMu.Lock()
+		defer h.localMu.Unlock()
+
+		events := []Event{}
+		for _, ev := range h.local[userID] {
+			if After(ev.ID, lastID) {
+				events = append(events, ev)
+			}
+		}
+		return events, nil
+	}
+
+	msgs, err := h.client.XRange(ctx, streamPrefix+userID, "("+lastID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(msgs))
+	for _, m := range msgs {
+		typ, _ := m.Values["type"].(string)
+		data, _ := m.Values["data"].(string)
+		events = append(events, Event{ID: m.ID, Type: typ, UserID: userID, Data: json.RawMessage(data)})
+	}
+	return events, nil
+}
+
+// Subscribe registers a local listener for the user's events. The channel
+// is closed when the subscriber falls behind or the hub stops, at which
+// point clients are expected to reconnect with Last-Event-ID. Once the hub
+// has stopped the channel is returned already closed.
+func (h *Hub) Subscribe(userID string) (<-chan Event, func()) {
+	ch := make(chan Event, bufferSize)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan Event]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() { h.remove(userID, ch) }
+}
+
+// record assigns the next stream-style ID to a local-mode event and adds
+// it to the user's replay history.
+func (h *Hub) record(ev Event) Event {
+	h.localMu.Lock()
+	defer h.localMu.Unlock()
+
+	ms := uint64(time.Now().UnixMilli())
+	if ms > h.localMs {
+		h.localMs, h.localSeq = ms, 0
+	} else {
+		h.localSeq++
+	}
+	ev.ID = strconv.FormatUint(h.localMs, 10) + "-" + strconv.FormatUint(h.localSeq, 10)
+
+	history := append(h.local[ev.UserID], ev)
+	if len(history) > streamMaxLen {
+		history = history[len(history)-streamMaxLen:]
+	}
+	h.local[ev.UserID] = history
+	h.sweep(time.UnixMilli(int64(h.localMs)))
+	return ev
+}
+
+// sweep drops the history of users who have had no event for streamTTL,
+// as their Redis stream would expire. It runs at most once per
+// localSweepInterval. The caller holds localMu.
+func (h *Hub) sweep(now time.Time) {
+	if now.Sub(h.localSwept) < localSweepInterval {
+		return
+	}
+	h.localSwept = now
+
+	cutoff := uint64(now.Add(-streamTTL).UnixMilli())
+	for userID, history := range h.local {
+		if ms, _ := splitID(history[len(history)-1].ID); ms < cutoff {
+			delete(h.local, userID)
+		}
+	}
+}
+
+func (h *Hub) dispatch(ev Event) {
+	h.mu.RLock()
+	var slow []chan Event
+	for ch := range h.subs[ev.UserID] {
+		select {
+		case ch <- ev:
+		default:
+			slow = append(slow, ch)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, ch := range slow {
+		h.remove(ev.UserID, ch)
+	}
+}
+
+func (h *Hub) remove(userID string, ch chan Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subs[userID][ch]; !ok {
+		return
+	}
+	delete(h.subs[userID], ch)
+	if len(h.subs[userID]) == 0 {
+		delete(h.subs, userID)
+	}
+	close(ch)
+}
+
+func (h *Hub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for userID, chans := range h.subs {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(h.subs, userID)
+	}
+}
+
+// Replayed holds the IDs of replayed events, so that the live events
+// overlapping a replay are sent once. Live events are otherwise sent as
+// they arrive: events of different replicas, or of concurrent publishers
+// on one node, can arrive out of ID order, and comparing IDs would drop
+// the late ones.
+type Replayed map[string]struct{}
+
+func NewReplayed(events []Event) Replayed {
+	r := make(Replayed, len(events))
+	for _, ev := range events {
+		r[ev.ID] = struct{}{}
+	}
+	return r
+}
+
+// Seen reports whether the live event ev was already replayed.
+func (r Replayed) Seen(ev Event) bool {
+	if _, ok := r[ev.ID]; !ok {
+		return false
+	}
+	// each event is published once, so it is not needed again
+	delete(r, ev.ID)
+	return true
+}
+
+// After reports whether stream ID a sorts after b ("<ms>-<seq>").
+func After(a, b string) bool {
+	if b == "" {
+		return true
+	}
+	am, as := splitID(a)
+	bm, bs := splitID(b)
+	if am != bm {
+		return am > bm
+	}
+	return as > bs
+}
+
+// ValidID reports whether id is a stream ID, "<ms>-<seq>" with both
+// parts decimal numbers.
+func ValidID(id string) bool {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return false
+	}
+	_, errMs := strconv.ParseUint(ms, 10, 64)
+	_, errSeq := strconv.ParseUint(seq, 10, 64)
+	return errMs == nil && errSeq == nil
+}
+
+func splitID(id string) (uint64, uint64) {
+	ms, seq, _ := strings.Cut(id, "-")
+	m, _ := strconv.ParseUint(ms, 10, 64)
+	s, _ := strconv.ParseUint(seq, 10, 64)
+	return m, s
+}
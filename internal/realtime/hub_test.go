@@ -0,0 +1,133 @@
+package realtime
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/developwithayush/go-todo-app/internal/logger"
+)
+
+func TestValidID(t *testing.T) {
+	for id, want := range map[string]bool{
+		"1700000000000-0":  true,
+		"0-0":              true,
+		"1700000000000-12": true,
+		"":                 false,
+		"-":                false,
+		"1700000000000":    false,
+		"1700000000000-":   false,
+		"-0":               false,
+		"+1-0":             false,
+		"1-0-0":            false,
+		"1-0) + (":         false,
+		"$":                false,
+	} {
+		if got := ValidID(id); got != want {
+			t.Errorf("ValidID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestLocalReplay(t *testing.T) {
+	hub := NewHub(nil, logger.Nop())
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := hub.Publish(ctx, "u1", EventTodoCreated, i); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	all, err := hub.Replay(ctx, "u1", "0-0")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("Replay from the start = %d events, %v; want 3", len(all), err)
+	}
+	rest, err := hub.Replay(ctx, "u1", all[0].ID)
+	if err != nil || len(rest) != 2 || rest[0].ID != all[1].ID {
+		t.Fatalf("Replay after the first event = %+v, %v; want the other two", rest, err)
+	}
+}
+
+func TestLocalHistoryIsCapped(t *testing.T) {
+	hub := NewHub(nil, logger.Nop())
+	for i := 0; i < streamMaxLen+10; i++ {
+		if err := hub.Publish(context.Background(), "u1", EventTodoCreated, i); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if n := len(hub.local["u1"]); n != streamMaxLen {
+		t.Fatalf("history has %d events, want %d", n, streamMaxLen)
+	}
+}
+
+func TestLocalHistoryExpires(t *testing.T) {
+	hub := NewHub(nil, logger.Nop())
+	old := time.Now().Add(-streamTTL - time.Minute).UnixMilli()
+	recent := time.Now().Add(-streamTTL + time.Hour).UnixMilli()
+	hub.local["idle"] = []Event{{ID: strconv.FormatInt(old, 10) + "-0", UserID: "idle"}}
+	hub.local["active"] = []Event{{ID: strconv.FormatInt(recent, 10) + "-0", UserID: "active"}}
+
+	if err := hub.Publish(context.Background(), "u1", EventTodoCreated, 1); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if _, ok := hub.local["idle"]; ok {
+		t.Error("history of a user idle for longer than the stream TTL was kept")
+	}
+	if _, ok := hub.local["active"]; !ok {
+		t.Error("history of a recently active user was dropped")
+	}
+	if len(hub.local["u1"]) != 1 {
+		t.Errorf("u1 history = %v, want the new event", hub.local["u1"])
+	}
+}
+
+func TestReplayedSkipsOnlyReplayedEvents(t *testing.T) {
+	replayed := NewReplayed([]Event{{ID: "5-0"}, {ID: "6-0"}})
+	for _, tc := range []struct {
+		id   string
+		seen bool
+	}{
+		{"5-0", true},
+		{"7-0", false},
+		// published before 6-0 on another replica but delivered after it
+		{"4-9", false},
+		{"6-0", true},
+		{"6-1", false},
+	} {
+		if got := replayed.Seen(Event{ID: tc.id}); got != tc.seen {
+			t.Errorf("Seen(%s) = %v, want %v", tc.id, got, tc.seen)
+		}
+	}
+}
+
+func TestLiveEventsOutOfOrderAreDelivered(t *testing.T) {
+	hub := NewHub(nil, logger.Nop())
+	ctx := context.Background()
+	events, unsubscribe := hub.Subscribe("u1")
+	defer unsubscribe()
+
+	// published after the subscription, so they arrive live and replayed
+	for i := 0; i < 2; i++ {
+		if err := hub.Publish(ctx, "u1", EventTodoCreated, i); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	backlog, err := hub.Replay(ctx, "u1", "0-0")
+	if err != nil || len(backlog) != 2 {
+		t.Fatalf("Replay = %d events, %v; want 2", len(backlog), err)
+	}
+	// an event recorded before the replayed ones but dispatched after
+	// them, as concurrent publishers can do
+	hub.dispatch(Event{ID: "1-0", Type: EventTodoUpdated, UserID: "u1"})
+
+	replayed := NewReplayed(backlog)
+	var sent []string
+	for len(events) > 0 {
+		if ev := <-events; !replayed.Seen(ev) {
+			sent = append(sent, ev.ID)
+		}
+	}
+	if len(sent) != 1 || sent[0] != "1-0" {
+		t.Fatalf("live events sent = %v, want only the late one", sent)
+	}
+}
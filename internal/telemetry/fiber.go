@@ -0,0 +1,59 @@
+package telemetry
+
+import (
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for every request, continuing the trace
+// from an incoming traceparent header. The span context is stored as the
+// request context, so spans started from c.Context() by the database,
+// Redis and mail clients become its children. The span is named after the
+// route template once routing is done.
+func Middleware() fiber.Handler {
+	tracer := Tracer()
+	propagator := otel.GetTextMapPropagator()
+
+	return func(c fiber.Ctx) error {
+		carrier := propagation.MapCarrier{}
+		for _, key := range propagator.Fields() {
+			if v := c.Get(key); v != "" {
+				carrier[key] = v
+			}
+		}
+		ctx := propagator.Extract(c.Context(), carrier)
+
+		ctx, span := tracer.Start(ctx, c.Method(), trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.ClientAddress(c.IP()),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+			))
+		defer span.End()
+		c.SetContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = apperr.Status(err)
+			span.RecordError(err)
+		}
+		if route := metrics.Route(c, status); route != "unmatched" {
+			span.SetName(c.Method() + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		return err
+	}
+}
@@ -0,0 +1,175 @@
+package telemetry
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+// tracedApp serves a few routes behind Middleware, with spans going to
+// the returned exporter.
+func tracedApp(t *testing.T) (*fiber.App, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	app := fiber.New()
+	app.Use(Middleware())
+	app.Get("/todos/:id", func(c fiber.Ctx) error {
+		// what the database clients do with the request context
+		_, span := Tracer().Start(c.Context(), "db.find")
+		span.End()
+		return c.SendString("ok")
+	})
+	app.Get("/boom", func(c fiber.Ctx) error {
+		return apperr.Internal("Failed", errors.New("storage down"))
+	})
+	app.Get("/missing", func(c fiber.Ctx) error {
+		return apperr.NotFound("todo_not_found", "Todo not found")
+	})
+	return app, exporter
+}
+
+func serve(t *testing.T, app *fiber.App, path string, header map[string]string) {
+	t.Helper()
+	req := httptest.NewRequest(fiber.MethodGet, path, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	resp.Body.Close()
+}
+
+func serverSpan(t *testing.T, exporter *tracetest.InMemoryExporter) tracetest.SpanStub {
+	t.Helper()
+	for _, span := range exporter.GetSpans() {
+		if span.SpanKind == trace.SpanKindServer {
+			return span
+		}
+	}
+	t.Fatalf("no server span in %v", exporter.GetSpans())
+	return tracetest.SpanStub{}
+}
+
+func attr(span tracetest.SpanStub, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestMiddlewareSpan(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantName   string
+		wantRoute  string
+		wantStatus int64
+		wantCode   codes.Code
+	}{
+		{"ok", "/todos/42", "GET /todos/:id", "/todos/:id", 200, codes.Unset},
+		{"server error", "/boom", "GET /boom", "/boom", 500, codes.Error},
+		{"client error", "/missing", "GET /missing", "/missing", 404, codes.Unset},
+		{"unmatched route", "/nowhere", "GET", "", 404, codes.Unset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, exporter := tracedApp(t)
+			serve(t, app, tt.path, nil)
+
+			span := serverSpan(t, exporter)
+			if span.Name != tt.wantName {
+				t.Errorf("span name = %q, want %q", span.Name, tt.wantName)
+			}
+			if got := attr(span, semconv.HTTPRouteKey).AsString(); got != tt.wantRoute {
+				t.Errorf("http.route = %q, want %q", got, tt.wantRoute)
+			}
+			if got := attr(span, semconv.HTTPResponseStatusCodeKey).AsInt64(); got != tt.wantStatus {
+				t.Errorf("http.response.status_code = %d, want %d", got, tt.wantStatus)
+			}
+			if got := attr(span, semconv.URLPathKey).AsString(); got != tt.path {
+				t.Errorf("url.path = %q, want %q", got, tt.path)
+			}
+			if span.Status.Code != tt.wantCode {
+				t.Errorf("span status = %v, want %v", span.Status.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRecordsError(t *testing.T) {
+	app, exporter := tracedApp(t)
+	serve(t, app, "/boom", nil)
+
+	span := serverSpan(t, exporter)
+	if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+		t.Fatalf("span events = %v, want the recorded error", span.Events)
+	}
+}
+
+func TestMiddlewareContinuesTrace(t *testing.T) {
+	app, exporter := tracedApp(t)
+	serve(t, app, "/todos/42", map[string]string{
+		"traceparent": "00-" + parentTraceID + "-" + parentSpanID + "-01",
+	})
+
+	server := serverSpan(t, exporter)
+	if got := server.SpanContext.TraceID().String(); got != parentTraceID {
+		t.Fatalf("server span trace = %s, want the incoming %s", got, parentTraceID)
+	}
+	if got := server.Parent.SpanID().String(); got != parentSpanID || !server.Parent.IsRemote() {
+		t.Fatalf("server span parent = %s (remote %v), want the incoming %s", got, server.Parent.IsRemote(), parentSpanID)
+	}
+
+	var child *tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == "db.find" {
+			child = &span
+		}
+	}
+	if child == nil {
+		t.Fatal("no span for the handler's database call")
+	}
+	if child.SpanContext.TraceID() != server.SpanContext.TraceID() || child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatalf("database span is not a child of the server span: parent %s, trace %s",
+			child.Parent.SpanID(), child.SpanContext.TraceID())
+	}
+}
+
+func TestMiddlewareStartsTrace(t *testing.T) {
+	app, exporter := tracedApp(t)
+	serve(t, app, "/todos/42", nil)
+
+	server := serverSpan(t, exporter)
+	if server.Parent.IsValid() {
+		t.Fatalf("server span without traceparent has parent %s", server.Parent.SpanID())
+	}
+}
@@ -1,9 +1,16 @@
 package util
 
 import (
+	"context"
+	"net"
 	"strconv"
 
 	"github.com/developwithayush/go-todo-app/internal/config"
+	"github.com/developwithayush/go-todo-app/internal/metrics"
+	"github.com/developwithayush/go-todo-app/internal/telemetry"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/gomail.v2"
 )
 
@@ -13,23 +20,46 @@ type Mailer struct {
 }
 
 func NewMailer(cfg *config.Config) (*Mailer, error) {
-	port, err := strconv.Atoi(cfg.SMTPPort)
-	if err != nil {
-		return nil, err
-	}
-
-	dialer := gomail.NewDialer(cfg.SMTPHost, port, cfg.SMTPUser, cfg.SMTPPass)
+	dialer := gomail.NewDialer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPass)
 	return &Mailer{
 		dialer: dialer,
 		from:   cfg.SMTPUser,
 	}, nil
 }
 
-func (m *Mailer) SendOTP(to, otp string) error {
+func (m *Mailer) SendOTP(ctx context.Context, to, otp string) error {
+	_, span := telemetry.Tracer().Start(ctx, "smtp send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.ServerAddress(m.dialer.Host),
+			semconv.ServerPort(m.dialer.Port),
+		))
+	defer span.End()
+
 	msg := gomail.NewMessage()
 	msg.SetHeader("From", m.from)
 	msg.SetHeader("To", to)
 	msg.SetHeader("Subject", "OTP for Go Todo App")
 	msg.SetBody("text/plain", "Your OTP is "+otp)
-	return m.dialer.DialAndSend(msg)
+
+	metrics.MailQueueDepth.Inc()
+	defer metrics.MailQueueDepth.Dec()
+	if err := m.dialer.DialAndSend(msg); err != nil {
+		metrics.MailSent.WithLabelValues("error").Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "send failed")
+		return err
+	}
+	metrics.MailSent.WithLabelValues("ok").Inc()
+	return nil
+}
+
+// Ping checks that the SMTP server accepts connections. It does not log in
+// or send anything.
+func (m *Mailer) Ping(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(m.dialer.Host, strconv.Itoa(m.dialer.Port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
 }
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag formats a resource version as a strong entity tag.
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// WeakETag formats a version as a weak entity tag, for representations
+// such as lists that are assembled rather than stored.
+func WeakETag(version int64) string {
+	return "W/" + ETag(version)
+}
+
+// MatchETag reports whether an If-Match or If-None-Match header value
+// matches etag. "*" matches anything and weak prefixes are ignored.
+func MatchETag(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
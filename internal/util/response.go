@@ -11,11 +11,3 @@ func OK(c fiber.Ctx, data interface{}) error {
 		"data":    data,
 	})
 } 
-
-
-func Error(c fiber.Ctx, status int, message string) error {
-	return c.Status(status).JSON(fiber.Map{
-		"success": false,
-		"message": message,
-	})
-}
\ No newline at end of file
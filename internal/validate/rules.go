@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/teambition/rrule-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rules are the tags this app adds to the validator's built-in ones.
+// timezone and hexcolor replace the built-in rules of the same name, which
+// also accept "Local" and alpha channels.
+var rules = map[string]validator.Func{
+	"objectid": isObjectID,
+	"timezone": isTimezone,
+	"rrule":    isRRule,
+	"hexcolor": isHexColor,
+}
+
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func isObjectID(fl validator.FieldLevel) bool {
+	return primitive.IsValidObjectID(fl.Field().String())
+}
+
+// isTimezone accepts IANA zone names such as Europe/Berlin, plus UTC.
+func isTimezone(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// isRRule accepts an RFC 5545 RRULE value, with or without the RRULE:
+// prefix, such as FREQ=WEEKLY;BYDAY=MO,WE.
+func isRRule(fl validator.FieldLevel) bool {
+	rule := strings.TrimPrefix(fl.Field().String(), "RRULE:")
+	if rule == "" {
+		return false
+	}
+	_, err := rrule.StrToROption(rule)
+	return err == nil
+}
+
+func isHexColor(fl validator.FieldLevel) bool {
+	return hexColorPattern.MatchString(fl.Field().String())
+}
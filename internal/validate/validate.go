@@ -0,0 +1,180 @@
+// Package validate checks request DTOs against their validate struct tags
+// and decodes JSON bodies strictly. Failures are apperr validation errors
+// with one FieldError per invalid field, named by its JSON path.
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrFailed is the error every validation failure matches.
+var ErrFailed = apperr.Validation("validation_failed", "Request validation failed")
+
+var std = newValidator()
+
+// Validator plugs Struct into Fiber, so c.Bind() validates every DTO it
+// binds. Set it as fiber.Config.StructValidator.
+type Validator struct{}
+
+func (Validator) Validate(out any) error {
+	return Struct(out)
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// report fields by the name clients send
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return f.Name
+		}
+		return name
+	})
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			panic(err)
+		}
+	}
+	return v
+}
+
+// Struct validates v, a struct or a pointer to one.
+func Struct(v any) error {
+	err := std.Struct(v)
+	if err == nil {
+		return nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	fields := make([]apperr.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fieldPath(fe.Namespace())
+		fields = append(fields, apperr.FieldError{Field: field, Code: fe.Tag(), Message: message(field, fe)})
+	}
+	return failed(fields...)
+}
+
+func failed(fields ...apperr.FieldError) error {
+	e := *ErrFailed
+	e.Fields = fields
+	return &e
+}
+
+// fieldPath drops the struct name from a namespace such as
+// BatchRequest.operations[0].id.
+func fieldPath(namespace string) string {
+	_, path, ok := strings.Cut(namespace, ".")
+	if !ok {
+		return namespace
+	}
+	return path
+}
+
+func message(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_unless", "required_if":
+		return field + " is required"
+	case "email":
+		return field + " must be a valid email address"
+	case "len":
+		return fmt.Sprintf("%s must be %s %s long", field, fe.Param(), unit(fe))
+	case "min":
+		return fmt.Sprintf("%s must be at least %s %s", field, fe.Param(), unit(fe))
+	case "max":
+		return fmt.Sprintf("%s must be at most %s %s", field, fe.Param(), unit(fe))
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "objectid":
+		return field + " must be a 24 character hex ID"
+	case "timezone":
+		return field + " must be an IANA time zone such as Europe/Berlin"
+	case "rrule":
+		return field + " must be an RFC 5545 recurrence rule"
+	case "hexcolor":
+		return field + " must be a hex color such as #1e90ff"
+	}
+	return field + " is invalid"
+}
+
+func unit(fe validator.FieldError) string {
+	var u string
+	switch fe.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		u = "item"
+	case reflect.String:
+		u = "character"
+	default:
+		return ""
+	}
+	if fe.Param() != "1" {
+		u += "s"
+	}
+	return u
+}
+
+// DecodeJSON is a strict json.Unmarshal for request bodies: unknown
+// fields and trailing data are errors. Set it as fiber.Config.JSONDecoder.
+func DecodeJSON(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return apperr.ErrInvalidBody.WithCause(errors.New("unexpected data after JSON value"))
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return failed(apperr.FieldError{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("%s must be %s", typeErr.Field, jsonType(typeErr.Type)),
+		})
+	}
+	// encoding/json has no error type for unknown fields
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		name = strings.Trim(name, `"`)
+		return failed(apperr.FieldError{Field: name, Code: "unknown", Message: name + " is not a known field"})
+	}
+	return apperr.ErrInvalidBody.WithCause(err)
+}
+
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return "a " + t.Kind().String()
+}
@@ -0,0 +1,165 @@
+package validate_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/developwithayush/go-todo-app/internal/apperr"
+	"github.com/developwithayush/go-todo-app/internal/dto"
+	apphttp "github.com/developwithayush/go-todo-app/internal/http"
+	"github.com/developwithayush/go-todo-app/internal/validate"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestRules(t *testing.T) {
+	type fields struct {
+		ID         string `json:"id" validate:"objectid"`
+		Timezone   string `json:"timezone" validate:"timezone"`
+		Recurrence string `json:"recurrence" validate:"rrule"`
+		Color      string `json:"color" validate:"hexcolor"`
+	}
+	valid := fields{ID: "507f1f77bcf86cd799439011", Timezone: "Europe/Berlin", Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE", Color: "#1e90ff"}
+
+	tests := []struct {
+		name  string
+		edit  func(*fields)
+		field string
+	}{
+		{"all valid", func(*fields) {}, ""},
+		{"UTC", func(f *fields) { f.Timezone = "UTC" }, ""},
+		{"rrule with prefix", func(f *fields) { f.Recurrence = "RRULE:FREQ=DAILY;COUNT=3" }, ""},
+		{"short hex color", func(f *fields) { f.Color = "#ABC" }, ""},
+		{"short object ID", func(f *fields) { f.ID = "507f1f77" }, "id"},
+		{"non-hex object ID", func(f *fields) { f.ID = "zzzzzzzzzzzzzzzzzzzzzzzz" }, "id"},
+		{"unknown timezone", func(f *fields) { f.Timezone = "Mars/Olympus" }, "timezone"},
+		{"local timezone", func(f *fields) { f.Timezone = "Local" }, "timezone"},
+		{"empty timezone", func(f *fields) { f.Timezone = "" }, "timezone"},
+		{"rrule without frequency", func(f *fields) { f.Recurrence = "BYDAY=MO" }, "recurrence"},
+		{"rrule with a bad frequency", func(f *fields) { f.Recurrence = "FREQ=FORTNIGHTLY" }, "recurrence"},
+		{"empty rrule", func(f *fields) { f.Recurrence = "RRULE:" }, "recurrence"},
+		{"color without hash", func(f *fields) { f.Color = "1e90ff" }, "color"},
+		{"color with alpha", func(f *fields) { f.Color = "#1e90ff80" }, "color"},
+		{"named color", func(f *fields) { f.Color = "red" }, "color"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := valid
+			tc.edit(&v)
+			err := validate.Struct(v)
+			if tc.field == "" {
+				if err != nil {
+					t.Fatalf("Struct: %v", err)
+				}
+				return
+			}
+			var ae *apperr.Error
+			if !errors.As(err, &ae) || len(ae.Fields) != 1 || ae.Fields[0].Field != tc.field {
+				t.Fatalf("Struct = %v, want one error for %s", err, tc.field)
+			}
+			if !errors.Is(err, validate.ErrFailed) {
+				t.Fatalf("Struct = %v, want ErrFailed", err)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONRejectsUnknownFields(t *testing.T) {
+	var body dto.CreateTodoRequest
+	err := validate.DecodeJSON([]byte(`{"title":"Buy milk","priority":"high"}`), &body)
+	var ae *apperr.Error
+	if !errors.As(err, &ae) || len(ae.Fields) != 1 {
+		t.Fatalf("DecodeJSON = %v, want one field error", err)
+	}
+	if f := ae.Fields[0]; f.Field != "priority" || f.Code != "unknown" {
+		t.Fatalf("field error = %+v, want priority unknown", f)
+	}
+
+	if err := validate.DecodeJSON([]byte(`{"title":"Buy milk"} {}`), &body); !errors.Is(err, apperr.ErrInvalidBody) {
+		t.Fatalf("DecodeJSON with trailing data = %v, want ErrInvalidBody", err)
+	}
+}
+
+func TestProblemListsEachInvalidField(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		ErrorHandler:    apphttp.ErrorHandler,
+		JSONDecoder:     validate.DecodeJSON,
+		StructValidator: validate.Validator{},
+	})
+	app.Post("/todos", func(c fiber.Ctx) error {
+		var body dto.CreateTodoRequest
+		if err := c.Bind().Body(&body); err != nil {
+			return apperr.InvalidBody(err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	post := func(body string) *dto.ErrorResponse {
+		t.Helper()
+		req := httptest.NewRequest(fiber.MethodPost, "/todos", strings.NewReader(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("POST /todos: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == fiber.StatusNoContent {
+			return nil
+		}
+		if ct := resp.Header.Get(fiber.HeaderContentType); ct != "application/problem+json" {
+			t.Fatalf("content type %q, want application/problem+json", ct)
+		}
+		var problem dto.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return &problem
+	}
+
+	if problem := post(`{"title":"Buy milk","color":"#1e90ff","recurrence":"FREQ=DAILY","timezone":"UTC"}`); problem != nil {
+		t.Fatalf("valid body got %+v", problem)
+	}
+	if problem := post(`{"title":"Buy milk"}`); problem != nil {
+		t.Fatalf("body without the optional fields got %+v", problem)
+	}
+
+	problem := post(`{"title":"","description":"` + strings.Repeat("x", 2001) + `","color":"blue","recurrence":"FREQ=NEVER","timezone":"Local"}`)
+	if problem == nil || problem.Status != fiber.StatusBadRequest || problem.Code != "validation_failed" {
+		t.Fatalf("problem = %+v, want a 400 validation_failed", problem)
+	}
+	want := map[string]dto.FieldError{
+		"title":       {Field: "title", Code: "required", Message: "title is required"},
+		"description": {Field: "description", Code: "max", Message: "description must be at most 2000 characters"},
+		"color":       {Field: "color", Code: "hexcolor", Message: "color must be a hex color such as #1e90ff"},
+		"recurrence":  {Field: "recurrence", Code: "rrule", Message: "recurrence must be an RFC 5545 recurrence rule"},
+		"timezone":    {Field: "timezone", Code: "timezone", Message: "timezone must be an IANA time zone such as Europe/Berlin"},
+	}
+	if len(problem.Errors) != len(want) {
+		t.Fatalf("errors = %+v, want one per invalid field", problem.Errors)
+	}
+	for _, got := range problem.Errors {
+		if got != want[got.Field] {
+			t.Errorf("error for %s = %+v, want %+v", got.Field, got, want[got.Field])
+		}
+	}
+
+	problem = post(`{"title":"Buy milk","priority":"high"}`)
+	if problem == nil || len(problem.Errors) != 1 || problem.Errors[0].Field != "priority" || problem.Errors[0].Code != "unknown" {
+		t.Fatalf("problem = %+v, want the unknown field listed", problem)
+	}
+}
+
+func TestOptionalPointerFields(t *testing.T) {
+	empty, red := "", "red"
+	if err := validate.Struct(dto.UpdateTodoRequest{Title: "Buy milk"}); err != nil {
+		t.Fatalf("omitted color: %v", err)
+	}
+	if err := validate.Struct(dto.UpdateTodoRequest{Title: "Buy milk", Color: &empty, Timezone: &empty}); err != nil {
+		t.Fatalf("empty color and timezone, which clear them: %v", err)
+	}
+	if err := validate.Struct(dto.UpdateTodoRequest{Title: "Buy milk", Color: &red}); err == nil {
+		t.Fatal("color red was accepted")
+	}
+}